@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 )
 
@@ -26,7 +27,7 @@ type DebuggerBackend interface {
 	AdapterID() string
 
 	// LaunchArgs builds the debugger-specific arguments map for DAP LaunchRequest.
-	LaunchArgs(mode, programPath string, stopOnEntry bool, programArgs []string) (map[string]any, error)
+	LaunchArgs(mode, programPath string, stopOnEntry bool, opts LaunchOptions) (map[string]any, error)
 
 	// CoreArgs builds the debugger-specific arguments map for core dump debugging.
 	CoreArgs(programPath, coreFilePath string) (map[string]any, error)
@@ -38,16 +39,57 @@ type DebuggerBackend interface {
 
 	// AttachArgs builds the debugger-specific arguments map for attaching to a process.
 	AttachArgs(processID int) (map[string]any, error)
+
+	// RemoteArgs builds the debugger-specific arguments map for the attach
+	// request sent to an already-running DAP server (remote mode).
+	RemoteArgs() (map[string]any, error)
+}
+
+// LaunchOptions holds the settings of a launched program beyond its path.
+type LaunchOptions struct {
+	Args       []string          // command line arguments
+	Env        map[string]string // environment variables, in addition to the server's own
+	Cwd        string            // working directory (default: the server's)
+	BuildFlags string            // flags for building the program (Delve's "source" mode only)
+	Output     string            // path of the built binary (Delve's "source" mode only)
+}
+
+// environ returns env as sorted "KEY=VALUE" strings.
+func environ(env map[string]string) []string {
+	vars := make([]string, 0, len(env))
+	for k, v := range env {
+		vars = append(vars, k+"="+v)
+	}
+	slices.Sort(vars)
+	return vars
+}
+
+// stdioBackend is implemented by backends whose TransportMode is "stdio".
+type stdioBackend interface {
+	// StdioPipes returns the adapter's stdout and stdin pipes captured by Spawn.
+	StdioPipes() (stdout io.ReadCloser, stdin io.WriteCloser)
 }
 
 // delveBackend implements DebuggerBackend for the Delve debugger (Go).
-type delveBackend struct{}
+type delveBackend struct {
+	dlvPath string // path to dlv binary (default: "dlv")
+	backend string // Delve's target backend: "native", "lldb" or "rr" (default: Delve's default)
+	logDAP  bool   // log the DAP traffic to the adapter's stderr
+}
 
 // Spawn starts a Delve DAP server process listening on the given port.
 // The port should be in ":PORT" format (e.g. ":0" for auto-assign).
 // It waits for the server to report its listen address on stdout.
 func (b *delveBackend) Spawn(port string, stderrWriter io.Writer) (*exec.Cmd, string, error) {
-	cmd := exec.Command("dlv", "dap", "--listen", port, "--log", "--log-output", "dap")
+	dlvPath := b.dlvPath
+	if dlvPath == "" {
+		dlvPath = "dlv"
+	}
+	args := []string{"dap", "--listen", port}
+	if b.logDAP {
+		args = append(args, "--log", "--log-output", "dap")
+	}
+	cmd := exec.Command(dlvPath, args...)
 	// Send adapter stderr to the provided writer, never to os.Stderr.
 	// With MCP stdio transport, os.Stderr is a pipe that can fill and block.
 	cmd.Stderr = stderrWriter
@@ -99,8 +141,22 @@ func (b *delveBackend) AdapterID() string {
 
 // LaunchArgs builds the Delve-specific argument map for a DAP LaunchRequest.
 // It translates the generic mode names ("source", "binary") into Delve's
-// mode names ("debug", "exec").
-func (b *delveBackend) LaunchArgs(mode, programPath string, stopOnEntry bool, programArgs []string) (map[string]any, error) {
+// mode names ("debug", "exec"). In "replay" mode, programPath is the rr
+// trace directory to replay, and opts are ignored.
+func (b *delveBackend) LaunchArgs(mode, programPath string, stopOnEntry bool, opts LaunchOptions) (map[string]any, error) {
+	if mode == "replay" {
+		// The program, its arguments and environment were fixed when it
+		// was recorded.
+		return map[string]any{
+			"request":      "launch",
+			"mode":         "replay",
+			"traceDirPath": programPath,
+			"backend":      "rr",
+			"stopOnEntry":  stopOnEntry,
+			"outputMode":   "remote",
+		}, nil
+	}
+
 	dlvMode := mode
 	switch mode {
 	case "source":
@@ -116,9 +172,30 @@ func (b *delveBackend) LaunchArgs(mode, programPath string, stopOnEntry bool, pr
 		"mode":        dlvMode,
 		"program":     programPath,
 		"stopOnEntry": stopOnEntry,
+		// Deliver the program's stdout/stderr as DAP OutputEvents rather
+		// than writing them to dlv's own stdout, which nothing reads after
+		// the listen address has been parsed.
+		"outputMode": "remote",
+	}
+	if b.backend != "" {
+		args["backend"] = b.backend
+	}
+	if len(opts.Args) > 0 {
+		args["args"] = opts.Args
 	}
-	if len(programArgs) > 0 {
-		args["args"] = programArgs
+	if len(opts.Env) > 0 {
+		args["env"] = opts.Env
+	}
+	if opts.Cwd != "" {
+		args["cwd"] = opts.Cwd
+	}
+	if mode == "source" {
+		if opts.BuildFlags != "" {
+			args["buildFlags"] = opts.BuildFlags
+		}
+		if opts.Output != "" {
+			args["output"] = opts.Output
+		}
 	}
 	return args, nil
 }
@@ -147,6 +224,17 @@ func (b *delveBackend) AttachArgs(processID int) (map[string]any, error) {
 	}, nil
 }
 
+// RemoteArgs builds the Delve-specific argument map for attaching to a
+// server started with 'dlv --headless --accept-multiclient'. The server
+// already controls the target, so remote mode needs no program or process
+// ID. A 'dlv dap' server has no target to attach to and rejects it.
+func (b *delveBackend) RemoteArgs() (map[string]any, error) {
+	return map[string]any{
+		"request": "attach",
+		"mode":    "remote",
+	}, nil
+}
+
 // gdbBackend implements DebuggerBackend for GDB's native DAP server.
 // Requires GDB 14+. Communicates over stdio.
 type gdbBackend struct {
@@ -211,22 +299,24 @@ func (g *gdbBackend) StdioPipes() (stdout io.ReadCloser, stdin io.WriteCloser) {
 // LaunchArgs builds the GDB native DAP argument map for a DAP LaunchRequest.
 // GDB does not support "source" mode; programs must be pre-compiled with
 // debug symbols (gcc -g -O0) and launched in "binary" mode.
-func (g *gdbBackend) LaunchArgs(mode, programPath string, stopOnEntry bool, programArgs []string) (map[string]any, error) {
+func (g *gdbBackend) LaunchArgs(mode, programPath string, stopOnEntry bool, opts LaunchOptions) (map[string]any, error) {
 	if mode == "source" {
 		return nil, fmt.Errorf("GDB does not support 'source' mode. Compile your program with debug symbols (gcc -g -O0) and use 'binary' mode instead")
 	}
 
-	cwd, _ := os.Getwd()
 	args := map[string]any{
 		"program": programPath,
-		"cwd":     cwd,
+		"cwd":     launchDir(opts.Cwd),
 		// GDB's native DAP distinguishes stopOnEntry (starti, first instruction)
 		// from stopAtBeginningOfMainSubprogram (start, main function).
 		// We use the latter since stopping at main is almost always the intent.
 		"stopAtBeginningOfMainSubprogram": stopOnEntry,
 	}
-	if len(programArgs) > 0 {
-		args["args"] = programArgs
+	if len(opts.Args) > 0 {
+		args["args"] = opts.Args
+	}
+	if len(opts.Env) > 0 {
+		args["env"] = opts.Env
 	}
 	return args, nil
 }
@@ -254,3 +344,265 @@ func (g *gdbBackend) AttachArgs(processID int) (map[string]any, error) {
 		"pid": processID,
 	}, nil
 }
+
+// RemoteArgs returns an error because GDB's native DAP server only runs over stdio.
+func (g *gdbBackend) RemoteArgs() (map[string]any, error) {
+	return nil, fmt.Errorf("GDB does not support remote mode; use gdbserver with 'binary' mode instead")
+}
+
+// lldbBackend implements DebuggerBackend for lldb-dap (formerly lldb-vscode),
+// the LLVM project's DAP server for C, C++, Rust, Swift and Objective-C.
+// Communicates over stdio.
+type lldbBackend struct {
+	lldbPath    string // path to lldb-dap binary (default: "lldb-dap")
+	toolLogPath string // path for lldb-dap's own log file
+	stdin       io.WriteCloser
+	stdout      io.ReadCloser
+}
+
+// Spawn starts lldb-dap over stdio. Like GDB, there is no listen address.
+func (l *lldbBackend) Spawn(port string, stderrWriter io.Writer) (*exec.Cmd, string, error) {
+	lldbPath := l.lldbPath
+	if lldbPath == "" {
+		lldbPath = "lldb-dap"
+	}
+	cmd := exec.Command(lldbPath)
+	cmd.Stderr = stderrWriter
+	if l.toolLogPath != "" {
+		// lldb-dap reads its log file path from the environment; older
+		// releases named lldb-vscode use the LLDBVSCODE_LOG variable.
+		cmd.Env = append(os.Environ(), "LLDBDAP_LOG="+l.toolLogPath, "LLDBVSCODE_LOG="+l.toolLogPath)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	l.stdin = stdin
+	l.stdout = stdout
+
+	if err := cmd.Start(); err != nil {
+		return nil, "", fmt.Errorf("failed to start lldb-dap: %w", err)
+	}
+
+	// stdio transport — no listen address
+	return cmd, "", nil
+}
+
+// TransportMode returns "stdio" because lldb-dap communicates over process
+// stdin/stdout.
+func (l *lldbBackend) TransportMode() string {
+	return "stdio"
+}
+
+// AdapterID returns "lldb-dap" for the LLDB DAP server.
+func (l *lldbBackend) AdapterID() string {
+	return "lldb-dap"
+}
+
+// StdioPipes returns the captured stdout and stdin pipes from Spawn.
+func (l *lldbBackend) StdioPipes() (stdout io.ReadCloser, stdin io.WriteCloser) {
+	return l.stdout, l.stdin
+}
+
+// LaunchArgs builds the lldb-dap argument map for a DAP LaunchRequest.
+// Like GDB, lldb-dap cannot build programs, so "source" mode is rejected.
+func (l *lldbBackend) LaunchArgs(mode, programPath string, stopOnEntry bool, opts LaunchOptions) (map[string]any, error) {
+	if mode == "source" {
+		return nil, fmt.Errorf("LLDB does not support 'source' mode. Compile your program with debug symbols (e.g. clang -g -O0 or cargo build) and use 'binary' mode instead")
+	}
+
+	args := map[string]any{
+		"program":     programPath,
+		"cwd":         launchDir(opts.Cwd),
+		"stopOnEntry": stopOnEntry,
+	}
+	if len(opts.Args) > 0 {
+		args["args"] = opts.Args
+	}
+	if len(opts.Env) > 0 {
+		// Older lldb-dap releases only accept "KEY=VALUE" strings.
+		args["env"] = environ(opts.Env)
+	}
+	return args, nil
+}
+
+// CoreRequestType returns "attach" because lldb-dap loads core files via the
+// attach request.
+func (l *lldbBackend) CoreRequestType() string {
+	return "attach"
+}
+
+// CoreArgs builds the lldb-dap argument map for core dump debugging.
+func (l *lldbBackend) CoreArgs(programPath, coreFilePath string) (map[string]any, error) {
+	return map[string]any{
+		"program":  programPath,
+		"coreFile": coreFilePath,
+	}, nil
+}
+
+// AttachArgs builds the lldb-dap argument map for attaching to a process.
+func (l *lldbBackend) AttachArgs(processID int) (map[string]any, error) {
+	return map[string]any{
+		"pid": processID,
+	}, nil
+}
+
+// RemoteArgs returns an error because remote mode is only supported with Delve.
+func (l *lldbBackend) RemoteArgs() (map[string]any, error) {
+	return nil, fmt.Errorf("LLDB does not support remote mode")
+}
+
+// debugpyBackend implements DebuggerBackend for Python via debugpy's DAP
+// adapter (python -m debugpy.adapter). Communicates over stdio.
+type debugpyBackend struct {
+	pythonPath string // path to a Python interpreter with debugpy installed (default: "python3")
+	justMyCode bool   // restrict stepping and breakpoints to user code
+	stdin      io.WriteCloser
+	stdout     io.ReadCloser
+}
+
+// Spawn starts the debugpy adapter over stdio. The adapter launches or
+// attaches to the debuggee itself, so there is no listen address.
+func (d *debugpyBackend) Spawn(port string, stderrWriter io.Writer) (*exec.Cmd, string, error) {
+	pythonPath := d.pythonPath
+	if pythonPath == "" {
+		pythonPath = "python3"
+	}
+	cmd := exec.Command(pythonPath, "-m", "debugpy.adapter")
+	cmd.Stderr = stderrWriter
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	d.stdin = stdin
+	d.stdout = stdout
+
+	if err := cmd.Start(); err != nil {
+		return nil, "", fmt.Errorf("failed to start debugpy adapter: %w (is debugpy installed? pip install debugpy)", err)
+	}
+
+	// stdio transport — no listen address
+	return cmd, "", nil
+}
+
+// TransportMode returns "stdio" because the debugpy adapter communicates
+// over process stdin/stdout.
+func (d *debugpyBackend) TransportMode() string {
+	return "stdio"
+}
+
+// AdapterID returns "debugpy" for the debugpy adapter.
+func (d *debugpyBackend) AdapterID() string {
+	return "debugpy"
+}
+
+// StdioPipes returns the captured stdout and stdin pipes from Spawn.
+func (d *debugpyBackend) StdioPipes() (stdout io.ReadCloser, stdin io.WriteCloser) {
+	return d.stdout, d.stdin
+}
+
+// LaunchArgs builds the debugpy argument map for a DAP LaunchRequest.
+// Python has no separate build step, so only "source" mode is supported:
+// a path to a .py file (or any existing file) is run as a script, and
+// anything else is run as a module, like python -m.
+func (d *debugpyBackend) LaunchArgs(mode, programPath string, stopOnEntry bool, opts LaunchOptions) (map[string]any, error) {
+	if mode != "source" {
+		return nil, fmt.Errorf("debugpy only supports 'source' mode for launching: pass a .py file or a module name as path")
+	}
+
+	args := map[string]any{
+		"type":        "python",
+		"request":     "launch",
+		"cwd":         launchDir(opts.Cwd),
+		"stopOnEntry": stopOnEntry,
+		"justMyCode":  d.justMyCode,
+		// Deliver the program's stdout/stderr as DAP OutputEvents rather
+		// than to a terminal, which an MCP session does not have.
+		"console": "internalConsole",
+	}
+	if isPythonScript(programPath) {
+		args["program"] = programPath
+	} else {
+		args["module"] = programPath
+	}
+	if len(opts.Args) > 0 {
+		args["args"] = opts.Args
+	}
+	if len(opts.Env) > 0 {
+		args["env"] = opts.Env
+	}
+	return args, nil
+}
+
+// isPythonScript reports whether path names a script file rather than a
+// module to run with -m.
+func isPythonScript(path string) bool {
+	if strings.HasSuffix(path, ".py") || strings.ContainsRune(path, os.PathSeparator) {
+		return true
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// CoreRequestType returns "" because debugpy cannot debug core dumps.
+func (d *debugpyBackend) CoreRequestType() string {
+	return ""
+}
+
+// CoreArgs returns an error because debugpy cannot debug core dumps.
+func (d *debugpyBackend) CoreArgs(programPath, coreFilePath string) (map[string]any, error) {
+	return nil, fmt.Errorf("debugpy does not support core dump debugging")
+}
+
+// AttachArgs builds the debugpy argument map for attaching to a running
+// Python process by PID. debugpy injects itself into the process, which may
+// require ptrace permissions.
+func (d *debugpyBackend) AttachArgs(processID int) (map[string]any, error) {
+	return map[string]any{
+		"type":       "python",
+		"request":    "attach",
+		"processId":  processID,
+		"justMyCode": d.justMyCode,
+	}, nil
+}
+
+// RemoteArgs returns an error because remote mode is only supported with Delve.
+func (d *debugpyBackend) RemoteArgs() (map[string]any, error) {
+	return nil, fmt.Errorf("debugpy does not support remote mode")
+}
+
+// launchDir returns cwd, or the server's working directory if cwd is empty,
+// for adapters that need an explicit working directory.
+func launchDir(cwd string) string {
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
+	return cwd
+}
+
+// lookPathAny returns the path of the first of names found in PATH.
+func lookPathAny(names ...string) (string, error) {
+	var firstErr error
+	for _, name := range names {
+		path, err := exec.LookPath(name)
+		if err == nil {
+			return path, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return "", firstErr
+}
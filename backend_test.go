@@ -3,6 +3,7 @@ package main
 import (
 	"io"
 	"os/exec"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -35,7 +36,7 @@ func TestDelveBackendLaunchArgs(t *testing.T) {
 	backend := &delveBackend{}
 
 	t.Run("source mode", func(t *testing.T) {
-		args, err := backend.LaunchArgs("source", "/path/to/main.go", true, nil)
+		args, err := backend.LaunchArgs("source", "/path/to/main.go", true, LaunchOptions{})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -51,10 +52,16 @@ func TestDelveBackendLaunchArgs(t *testing.T) {
 		if _, ok := args["args"]; ok {
 			t.Error("expected no args key when programArgs is nil")
 		}
+		if args["outputMode"] != "remote" {
+			t.Errorf("expected outputMode 'remote', got: %v", args["outputMode"])
+		}
+		if _, ok := args["backend"]; ok {
+			t.Error("expected no backend key by default")
+		}
 	})
 
 	t.Run("binary mode", func(t *testing.T) {
-		args, err := backend.LaunchArgs("binary", "/path/to/binary", false, []string{"--flag", "value"})
+		args, err := backend.LaunchArgs("binary", "/path/to/binary", false, LaunchOptions{Args: []string{"--flag", "value"}})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -77,11 +84,63 @@ func TestDelveBackendLaunchArgs(t *testing.T) {
 	})
 
 	t.Run("unsupported mode", func(t *testing.T) {
-		_, err := backend.LaunchArgs("invalid", "/path", false, nil)
+		_, err := backend.LaunchArgs("invalid", "/path", false, LaunchOptions{})
 		if err == nil {
 			t.Error("expected error for unsupported mode")
 		}
 	})
+
+	t.Run("rr backend", func(t *testing.T) {
+		args, err := (&delveBackend{backend: "rr"}).LaunchArgs("source", "/path/to/main.go", false, LaunchOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if args["mode"] != "debug" || args["backend"] != "rr" {
+			t.Errorf("expected mode 'debug' with backend 'rr', got: %v, %v", args["mode"], args["backend"])
+		}
+	})
+
+	t.Run("launch options", func(t *testing.T) {
+		opts := LaunchOptions{
+			Env:        map[string]string{"CONFIG_PATH": "/etc/app.yaml"},
+			Cwd:        "/srv/app",
+			BuildFlags: "-tags=integration",
+			Output:     "/tmp/app.debug",
+		}
+		args, err := backend.LaunchArgs("source", "/path/to/main.go", false, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if args["buildFlags"] != "-tags=integration" || args["output"] != "/tmp/app.debug" || args["cwd"] != "/srv/app" {
+			t.Errorf("expected buildFlags, output and cwd from the options, got: %v, %v, %v", args["buildFlags"], args["output"], args["cwd"])
+		}
+		if env, ok := args["env"].(map[string]string); !ok || env["CONFIG_PATH"] != "/etc/app.yaml" {
+			t.Errorf("expected env with CONFIG_PATH, got: %v", args["env"])
+		}
+		args, err = backend.LaunchArgs("binary", "/path/to/prog", false, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := args["buildFlags"]; ok {
+			t.Error("expected no buildFlags key for a binary, which is not built")
+		}
+		if args["cwd"] != "/srv/app" {
+			t.Errorf("expected cwd '/srv/app' for a binary, got: %v", args["cwd"])
+		}
+	})
+
+	t.Run("replay trace", func(t *testing.T) {
+		args, err := backend.LaunchArgs("replay", "/tmp/trace", false, LaunchOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if args["mode"] != "replay" || args["traceDirPath"] != "/tmp/trace" || args["backend"] != "rr" {
+			t.Errorf("expected mode 'replay' with traceDirPath '/tmp/trace' and backend 'rr', got: %v, %v, %v", args["mode"], args["traceDirPath"], args["backend"])
+		}
+		if _, ok := args["program"]; ok {
+			t.Error("expected no program key when replaying a trace")
+		}
+	})
 }
 
 func TestDelveBackendCoreArgs(t *testing.T) {
@@ -118,7 +177,7 @@ func TestDelveBackendAttachArgs(t *testing.T) {
 func TestGDBBackendLaunchArgs(t *testing.T) {
 	backend := &gdbBackend{gdbPath: "gdb"}
 
-	args, err := backend.LaunchArgs("binary", "/path/to/prog", false, []string{"--flag"})
+	args, err := backend.LaunchArgs("binary", "/path/to/prog", false, LaunchOptions{Args: []string{"--flag"}})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -146,12 +205,23 @@ func TestGDBBackendLaunchArgs(t *testing.T) {
 	if _, ok := args["miDebuggerPath"]; ok {
 		t.Error("unexpected miDebuggerPath key (cpptools artifact)")
 	}
+
+	args, err = backend.LaunchArgs("binary", "/path/to/prog", false, LaunchOptions{Env: map[string]string{"CONFIG_PATH": "/etc/app.yaml"}, Cwd: "/srv/app"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args["cwd"] != "/srv/app" {
+		t.Errorf("expected cwd=/srv/app, got: %v", args["cwd"])
+	}
+	if env, ok := args["env"].(map[string]string); !ok || env["CONFIG_PATH"] != "/etc/app.yaml" {
+		t.Errorf("expected env with CONFIG_PATH, got: %v", args["env"])
+	}
 }
 
 func TestGDBBackendSourceModeError(t *testing.T) {
 	backend := &gdbBackend{gdbPath: "gdb"}
 
-	_, err := backend.LaunchArgs("source", "/path/to/prog", false, nil)
+	_, err := backend.LaunchArgs("source", "/path/to/prog", false, LaunchOptions{})
 	if err == nil {
 		t.Fatal("expected error for source mode with GDB")
 	}
@@ -221,3 +291,184 @@ func TestGDBBackendSpawn(t *testing.T) {
 		t.Errorf("expected stdio transport, got: %s", backend.TransportMode())
 	}
 }
+
+func TestLLDBBackendLaunchArgs(t *testing.T) {
+	backend := &lldbBackend{lldbPath: "lldb-dap"}
+
+	args, err := backend.LaunchArgs("binary", "/path/to/prog", true, LaunchOptions{Args: []string{"--flag"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args["program"] != "/path/to/prog" {
+		t.Errorf("expected program=/path/to/prog, got: %v", args["program"])
+	}
+	if args["stopOnEntry"] != true {
+		t.Errorf("expected stopOnEntry=true, got: %v", args["stopOnEntry"])
+	}
+	if _, ok := args["cwd"]; !ok {
+		t.Error("expected cwd to be set")
+	}
+	programArgs, ok := args["args"].([]string)
+	if !ok {
+		t.Fatalf("expected args to be []string, got: %T", args["args"])
+	}
+	if len(programArgs) != 1 || programArgs[0] != "--flag" {
+		t.Errorf("unexpected args: %v", programArgs)
+	}
+
+	args, err = backend.LaunchArgs("binary", "/path/to/prog", false, LaunchOptions{Env: map[string]string{"B": "2", "A": "1"}, Cwd: "/srv/app"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args["cwd"] != "/srv/app" {
+		t.Errorf("expected cwd=/srv/app, got: %v", args["cwd"])
+	}
+	if env, ok := args["env"].([]string); !ok || !slices.Equal(env, []string{"A=1", "B=2"}) {
+		t.Errorf("expected env [A=1 B=2], got: %v", args["env"])
+	}
+
+	if _, err := backend.LaunchArgs("source", "/path/to/prog", false, LaunchOptions{}); err == nil {
+		t.Error("expected error for source mode with LLDB")
+	}
+}
+
+func TestLLDBBackendCoreArgs(t *testing.T) {
+	backend := &lldbBackend{lldbPath: "lldb-dap"}
+	if backend.CoreRequestType() != "attach" {
+		t.Errorf("expected core request type 'attach', got: %s", backend.CoreRequestType())
+	}
+	args, err := backend.CoreArgs("/path/to/program", "/path/to/core")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args["program"] != "/path/to/program" {
+		t.Errorf("expected program '/path/to/program', got: %v", args["program"])
+	}
+	if args["coreFile"] != "/path/to/core" {
+		t.Errorf("expected coreFile '/path/to/core', got: %v", args["coreFile"])
+	}
+}
+
+func TestLLDBBackendAttachArgs(t *testing.T) {
+	backend := &lldbBackend{lldbPath: "lldb-dap"}
+	args, err := backend.AttachArgs(12345)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args["pid"] != 12345 {
+		t.Errorf("expected pid 12345, got: %v", args["pid"])
+	}
+}
+
+func TestLLDBBackendSpawn(t *testing.T) {
+	lldbPath, err := lookPathAny("lldb-dap", "lldb-vscode")
+	if err != nil {
+		t.Skip("lldb-dap not found in PATH")
+	}
+
+	backend := &lldbBackend{lldbPath: lldbPath}
+	cmd, listenAddr, err := backend.Spawn(":0", io.Discard)
+	if err != nil {
+		t.Fatalf("failed to spawn lldb-dap: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	if listenAddr != "" {
+		t.Errorf("expected empty listen address for stdio transport, got: %s", listenAddr)
+	}
+	if backend.TransportMode() != "stdio" {
+		t.Errorf("expected stdio transport, got: %s", backend.TransportMode())
+	}
+}
+
+func TestDebugpyBackendLaunchArgs(t *testing.T) {
+	backend := &debugpyBackend{pythonPath: "python3", justMyCode: true}
+
+	t.Run("script", func(t *testing.T) {
+		args, err := backend.LaunchArgs("source", "/path/to/tool.py", false, LaunchOptions{Args: []string{"--flag"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if args["program"] != "/path/to/tool.py" {
+			t.Errorf("expected program '/path/to/tool.py', got: %v", args["program"])
+		}
+		if _, ok := args["module"]; ok {
+			t.Error("expected no module key for a script")
+		}
+		if args["justMyCode"] != true {
+			t.Errorf("expected justMyCode true, got: %v", args["justMyCode"])
+		}
+		if args["console"] != "internalConsole" {
+			t.Errorf("expected console 'internalConsole', got: %v", args["console"])
+		}
+		if _, ok := args["cwd"]; !ok {
+			t.Error("expected cwd to be set")
+		}
+		programArgs, ok := args["args"].([]string)
+		if !ok || len(programArgs) != 1 || programArgs[0] != "--flag" {
+			t.Errorf("unexpected args: %v", args["args"])
+		}
+	})
+
+	t.Run("module", func(t *testing.T) {
+		args, err := backend.LaunchArgs("source", "mypkg.tool", true, LaunchOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if args["module"] != "mypkg.tool" {
+			t.Errorf("expected module 'mypkg.tool', got: %v", args["module"])
+		}
+		if _, ok := args["program"]; ok {
+			t.Error("expected no program key for a module")
+		}
+		if args["stopOnEntry"] != true {
+			t.Errorf("expected stopOnEntry true, got: %v", args["stopOnEntry"])
+		}
+	})
+
+	t.Run("binary mode", func(t *testing.T) {
+		if _, err := backend.LaunchArgs("binary", "/path/to/tool.py", false, LaunchOptions{}); err == nil {
+			t.Error("expected error for binary mode with debugpy")
+		}
+	})
+}
+
+func TestDebugpyBackendAttachArgs(t *testing.T) {
+	backend := &debugpyBackend{pythonPath: "python3", justMyCode: false}
+	args, err := backend.AttachArgs(12345)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args["processId"] != 12345 {
+		t.Errorf("expected processId 12345, got: %v", args["processId"])
+	}
+	if args["justMyCode"] != false {
+		t.Errorf("expected justMyCode false, got: %v", args["justMyCode"])
+	}
+}
+
+func TestDebugpyBackendCoreArgs(t *testing.T) {
+	backend := &debugpyBackend{pythonPath: "python3"}
+	if _, err := backend.CoreArgs("/path/to/program", "/path/to/core"); err == nil {
+		t.Error("expected error for core mode with debugpy")
+	}
+}
+
+func TestRemoteArgs(t *testing.T) {
+	args, err := (&delveBackend{}).RemoteArgs()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args["request"] != "attach" || args["mode"] != "remote" {
+		t.Errorf("expected attach request in remote mode, got: %v", args)
+	}
+
+	for _, backend := range []DebuggerBackend{&gdbBackend{}, &lldbBackend{}, &debugpyBackend{}} {
+		if _, err := backend.RemoteArgs(); err == nil {
+			t.Errorf("%T: expected error for remote mode", backend)
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Thread states reported by 'analyze-blocking' besides the blocking
+// primitives in blockingFunctions.
+const (
+	stateRunning = "running" // not blocked: executing, or stopped at a breakpoint
+	stateParked  = "parked"  // parked by the Go runtime for an unrecognized reason
+	stateRuntime = "runtime" // a Go runtime goroutine, such as the garbage collector's
+)
+
+// blockingPrimitive describes a function threads block in.
+type blockingPrimitive struct {
+	state string // what the thread waits for, e.g. "chan receive"
+	arg   string // parameter naming the object waited on, if it can be read
+	wakes bool   // the wait ends by itself (timer or I/O), so it cannot deadlock
+}
+
+// blockingFunctions maps the functions of the Go runtime and standard
+// library, and of glibc for GDB and LLDB sessions, in which threads block
+// to the primitive they block on. A thread is classified by its innermost
+// frame found here.
+var blockingFunctions = map[string]blockingPrimitive{
+	// Go
+	"runtime.chansend":               {state: "chan send", arg: "c"},
+	"runtime.chanrecv":               {state: "chan receive", arg: "c"},
+	"runtime.selectgo":               {state: "select"},
+	"runtime.block":                  {state: "select"}, // select {}
+	"sync.(*Mutex).Lock":             {state: "mutex", arg: "m"},
+	"sync.(*Mutex).lockSlow":         {state: "mutex", arg: "m"},
+	"sync.(*RWMutex).Lock":           {state: "rwmutex lock", arg: "rw"},
+	"sync.(*RWMutex).RLock":          {state: "rwmutex read lock", arg: "rw"},
+	"sync.(*WaitGroup).Wait":         {state: "waitgroup", arg: "wg"},
+	"sync.(*Cond).Wait":              {state: "cond", arg: "c"},
+	"time.Sleep":                     {state: "sleep", wakes: true},
+	"runtime.netpollblock":           {state: "netpoll", wakes: true},
+	"internal/poll.runtime_pollWait": {state: "netpoll", wakes: true},
+
+	// C, with glibc
+	"pthread_mutex_lock":     {state: "pthread mutex", arg: "mutex"},
+	"__pthread_mutex_lock":   {state: "pthread mutex", arg: "mutex"},
+	"___pthread_mutex_lock":  {state: "pthread mutex", arg: "mutex"},
+	"pthread_cond_wait":      {state: "pthread condvar", arg: "cond"},
+	"__pthread_cond_wait":    {state: "pthread condvar", arg: "cond"},
+	"___pthread_cond_wait":   {state: "pthread condvar", arg: "cond"},
+	"pthread_cond_timedwait": {state: "pthread condvar", arg: "cond", wakes: true},
+	"pthread_join":           {state: "pthread join"},
+	"__pthread_clockjoin_ex": {state: "pthread join"},
+	"nanosleep":              {state: "sleep", wakes: true},
+	"__nanosleep":            {state: "sleep", wakes: true},
+	"clock_nanosleep":        {state: "sleep", wakes: true},
+	"__clock_nanosleep":      {state: "sleep", wakes: true},
+	"epoll_wait":             {state: "I/O wait", wakes: true},
+	"poll":                   {state: "I/O wait", wakes: true},
+	"__poll":                 {state: "I/O wait", wakes: true},
+	"select":                 {state: "I/O wait", wakes: true},
+	"accept":                 {state: "I/O wait", wakes: true},
+	"accept4":                {state: "I/O wait", wakes: true},
+}
+
+// AnalyzeBlockingParams defines the parameters for 'analyze-blocking'.
+type AnalyzeBlockingParams struct {
+	SessionParam
+	Depth   FlexInt `json:"depth,omitempty" mcp:"stack frames examined per thread (default: 20)"`
+	Timeout FlexInt `json:"timeout,omitempty" mcp:"seconds to wait for a running program to pause (default: 30)"`
+}
+
+// analyzeBlocking pauses the program if it is running, and classifies every
+// thread (goroutine, with Delve) by what it is blocked on, inferring which
+// threads wait on the same channel or lock.
+func (ds *debuggerSession) analyzeBlocking(ctx context.Context, _ *mcp.CallToolRequest, params AnalyzeBlockingParams) (*mcp.CallToolResult, *BlockingResult, error) {
+	ds.mu.Lock()
+	if ds.client == nil {
+		ds.mu.Unlock()
+		return nil, nil, fmt.Errorf("debugger not started")
+	}
+	rs := ds.running
+	if rs != nil {
+		seq, err := ds.client.PauseRequest(ds.defaultThreadID())
+		if err == nil {
+			err = readAndValidateResponse(ds.client, seq, "unable to pause execution")
+		}
+		if err != nil {
+			ds.mu.Unlock()
+			return nil, nil, err
+		}
+	}
+	ds.mu.Unlock()
+
+	timeout := runTimeout(params.Timeout)
+	if rs != nil {
+		finished, err := waitForStop(ctx, rs, timeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !finished {
+			return nil, nil, fmt.Errorf("program did not pause within %s", timeout)
+		}
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if rs != nil {
+		stop, err := ds.finishRun(rs, stopDetail{})
+		if err != nil {
+			return nil, nil, err
+		}
+		if stop.Status == statusTerminated {
+			return nil, nil, fmt.Errorf("program terminated")
+		}
+	}
+	if ds.client == nil {
+		return nil, nil, fmt.Errorf("debugger not started")
+	}
+
+	seq, err := ds.client.ThreadsRequest()
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := readTypedResponse[*dap.ThreadsResponse](ds.client, seq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get threads: %w", err)
+	}
+	threads := make([]Thread, len(resp.Body.Threads))
+	for i, t := range resp.Body.Threads {
+		threads[i] = Thread{ID: t.Id, Name: t.Name}
+	}
+	depth := params.Depth.Int()
+	if depth <= 0 {
+		depth = defaultMaxFrames
+	}
+	ds.fetchThreadStacks(threads, depth)
+
+	out := &BlockingResult{Threads: make([]BlockedThread, len(threads))}
+	var lookups []objectLookup
+	for i, t := range threads {
+		bt, frame, prim := classifyThread(t)
+		out.Threads[i] = bt
+		if prim.arg != "" {
+			lookups = append(lookups, objectLookup{thread: &out.Threads[i], frameID: frame.ID, arg: prim.arg})
+		}
+	}
+	ds.lookupObjects(lookups)
+	out.Relations = waitRelations(out.Threads, threads)
+	out.Deadlock = isDeadlocked(out.Threads)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: ds.formatBlocking(out)}},
+	}, out, nil
+}
+
+// classifyThread finds what t is blocked on from its stack. It returns the
+// classification, and the frame and primitive it is based on.
+func classifyThread(t Thread) (BlockedThread, StackFrame, blockingPrimitive) {
+	bt := BlockedThread{ID: t.ID, Name: t.Name, State: stateRunning}
+	if len(t.Frames) == 0 {
+		return bt, StackFrame{}, blockingPrimitive{}
+	}
+	for i, f := range t.Frames {
+		prim, ok := blockingFunctions[functionName(f.Name)]
+		if !ok {
+			continue
+		}
+		bt.State = prim.state
+		if j := callerFrame(t.Frames, i); j >= 0 {
+			bt.Location = &t.Frames[j]
+		}
+		return bt, f, prim
+	}
+	if !slices.ContainsFunc(t.Frames, func(f StackFrame) bool { return !strings.HasPrefix(f.Name, "runtime.") }) {
+		bt.State = stateRuntime
+		return bt, StackFrame{}, blockingPrimitive{}
+	}
+	if t.Frames[0].Name == "runtime.gopark" {
+		bt.State = stateParked
+	}
+	if j := callerFrame(t.Frames, -1); j >= 0 {
+		bt.Location = &t.Frames[j]
+	}
+	return bt, StackFrame{}, blockingPrimitive{}
+}
+
+// functionName strips the symbol version from a C function name, as in
+// pthread_cond_wait@@GLIBC_2.3.2.
+func functionName(name string) string {
+	name, _, _ = strings.Cut(name, "@")
+	return name
+}
+
+// callerFrame returns the index of the first frame after frames[i] in the
+// program's own code: outside the Go runtime and the package of frames[i].
+// It returns -1 if there is none.
+func callerFrame(frames []StackFrame, i int) int {
+	pkg := ""
+	if i >= 0 {
+		pkg = goPackage(frames[i].Name)
+	}
+	for j := i + 1; j < len(frames); j++ {
+		name := frames[j].Name
+		if frames[j].Runtime || strings.HasPrefix(name, "runtime.") || strings.HasPrefix(name, "internal/") {
+			continue
+		}
+		if pkg != "" && goPackage(name) == pkg {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// goPackage returns the package path of a Go function name such as
+// "sync.(*Mutex).Lock", or "" for a C function name.
+func goPackage(name string) string {
+	slash := strings.LastIndex(name, "/") + 1
+	if dot := strings.Index(name[slash:], "."); dot >= 0 {
+		return name[:slash+dot]
+	}
+	return ""
+}
+
+// objectLookup is a request to read the address of the object a thread
+// waits on from the parameter arg of a frame.
+type objectLookup struct {
+	thread  *BlockedThread
+	frameID int
+	arg     string
+}
+
+// addressPattern finds the address in an evaluated pointer.
+var addressPattern = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+
+// lookupObjects fills in the objects the threads wait on, and for pthread
+// mutexes, their owners. The evaluations are pipelined in batches, like
+// the stack requests in fetchThreadStacks. Objects that cannot be read,
+// for example without debug info for the C library, are left empty.
+func (ds *debuggerSession) lookupObjects(lookups []objectLookup) {
+	// uintptr() makes Delve print the pointer rather than the object.
+	addressExpr := "(void *)%s"
+	if ds.debugger == "delve" {
+		addressExpr = "uintptr(%s)"
+	}
+	for batch := range slices.Chunk(lookups, stackRequestBatch) {
+		type pending struct{ object, owner int }
+		seqs := make([]pending, len(batch))
+		for i, l := range batch {
+			seqs[i] = pending{-1, -1}
+			if seq, err := ds.client.EvaluateRequest(fmt.Sprintf(addressExpr, l.arg), l.frameID, "watch"); err == nil {
+				seqs[i].object = seq
+			}
+			if l.thread.State == "pthread mutex" {
+				// glibc records the LWP of the thread holding a mutex.
+				if seq, err := ds.client.EvaluateRequest(l.arg+"->__data.__owner", l.frameID, "watch"); err == nil {
+					seqs[i].owner = seq
+				}
+			}
+		}
+		for i, p := range seqs {
+			if p.object >= 0 {
+				if resp, err := readTypedResponse[*dap.EvaluateResponse](ds.client, p.object); err == nil {
+					batch[i].thread.Object = addressPattern.FindString(resp.Body.Result)
+				}
+			}
+			if p.owner >= 0 {
+				if resp, err := readTypedResponse[*dap.EvaluateResponse](ds.client, p.owner); err == nil {
+					if lwp, err := strconv.Atoi(strings.TrimSpace(resp.Body.Result)); err == nil && lwp > 0 {
+						batch[i].thread.OwnerLWP = lwp
+					}
+				}
+			}
+		}
+	}
+}
+
+// waitRelations groups the threads waiting on the same object, and notes
+// what can be inferred about who they wait for.
+func waitRelations(blocked []BlockedThread, threads []Thread) []WaitRelation {
+	var relations []WaitRelation
+	index := make(map[string]int) // by state and object
+	for _, bt := range blocked {
+		if bt.Object == "" {
+			continue
+		}
+		key := bt.State + " " + bt.Object
+		i, ok := index[key]
+		if !ok {
+			i = len(relations)
+			index[key] = i
+			relations = append(relations, WaitRelation{State: bt.State, Object: bt.Object})
+		}
+		relations[i].Waiters = append(relations[i].Waiters, bt.ID)
+		if bt.OwnerLWP != 0 && relations[i].Owner == 0 {
+			relations[i].Owner = threadByLWP(threads, bt.OwnerLWP)
+			relations[i].OwnerLWP = bt.OwnerLWP
+		}
+	}
+	for i := range relations {
+		r := &relations[i]
+		n := len(r.Waiters)
+		switch r.State {
+		case "chan receive":
+			r.Note = fmt.Sprintf("%d waiting to receive", n)
+			if _, ok := index["chan send "+r.Object]; !ok {
+				r.Note += "; no goroutine is blocked sending on it"
+			}
+		case "chan send":
+			r.Note = fmt.Sprintf("%d waiting to send", n)
+			if _, ok := index["chan receive "+r.Object]; !ok {
+				r.Note += "; no goroutine is blocked receiving from it"
+			}
+		case "mutex", "rwmutex lock", "rwmutex read lock":
+			r.Note = fmt.Sprintf("%d waiting to lock; Go does not record which goroutine holds it", n)
+		case "pthread mutex":
+			r.Note = fmt.Sprintf("%d waiting to lock", n)
+			switch {
+			case r.Owner != 0:
+				r.Note += fmt.Sprintf("; held by thread %d (LWP %d)", r.Owner, r.OwnerLWP)
+			case r.OwnerLWP != 0:
+				r.Note += fmt.Sprintf("; held by LWP %d", r.OwnerLWP)
+			}
+			if r.Owner != 0 && slices.Contains(r.Waiters, r.Owner) {
+				r.Note += ", which is itself waiting for it"
+			}
+		case "waitgroup":
+			r.Note = fmt.Sprintf("%d waiting for Done calls", n)
+		default:
+			r.Note = fmt.Sprintf("%d waiting", n)
+		}
+	}
+	// Mark lock cycles between pthread mutexes: a holder waiting on a
+	// mutex held by one of the waiters.
+	for i := range relations {
+		r := &relations[i]
+		if r.Owner == 0 {
+			continue
+		}
+		for _, other := range relations {
+			if other.Object != r.Object && other.Owner != 0 && slices.Contains(other.Waiters, r.Owner) && slices.Contains(r.Waiters, other.Owner) {
+				r.Note += fmt.Sprintf("; deadlock: thread %d holds it and waits for %s, held by thread %d", r.Owner, other.Object, other.Owner)
+				break
+			}
+		}
+	}
+	return relations
+}
+
+// lwpPattern finds the LWP in a GDB thread name such as
+// "Thread 0x7ffff7d8a740 (LWP 1234)".
+var lwpPattern = regexp.MustCompile(`LWP (\d+)`)
+
+// threadByLWP returns the ID of the thread whose name shows the given LWP,
+// or 0.
+func threadByLWP(threads []Thread, lwp int) int {
+	for _, t := range threads {
+		if m := lwpPattern.FindStringSubmatch(t.Name); m != nil && m[1] == strconv.Itoa(lwp) {
+			return t.ID
+		}
+	}
+	return 0
+}
+
+// isDeadlocked reports whether every thread is blocked on something that
+// cannot end by itself.
+func isDeadlocked(threads []BlockedThread) bool {
+	blocked := false
+	for _, bt := range threads {
+		switch bt.State {
+		case stateRuntime:
+		case stateRunning, stateParked:
+			return false
+		default:
+			if wakes(bt.State) {
+				return false
+			}
+			blocked = true
+		}
+	}
+	return blocked
+}
+
+// wakes reports whether a wait in state ends by itself.
+func wakes(state string) bool {
+	for _, prim := range blockingFunctions {
+		if prim.state == state && prim.wakes {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBlocking renders an 'analyze-blocking' result. Threads in the same
+// state at the same location are listed together.
+func (ds *debuggerSession) formatBlocking(out *BlockingResult) string {
+	noun := "threads"
+	if ds.debugger == "delve" {
+		noun = "goroutines"
+	}
+	var result strings.Builder
+	counts := make(map[string]int)
+	var states []string
+	for _, bt := range out.Threads {
+		if counts[bt.State] == 0 {
+			states = append(states, bt.State)
+		}
+		counts[bt.State]++
+	}
+	slices.SortStableFunc(states, func(a, b string) int { return counts[b] - counts[a] })
+	summary := make([]string, len(states))
+	for i, s := range states {
+		summary[i] = fmt.Sprintf("%d %s", counts[s], s)
+	}
+	fmt.Fprintf(&result, "Analyzed %d %s: %s\n", len(out.Threads), noun, strings.Join(summary, ", "))
+	if out.Deadlock {
+		fmt.Fprintf(&result, "DEADLOCK: no %s is running, and none is waiting on a timer or I/O.\n", strings.TrimSuffix(noun, "s"))
+	}
+
+	if len(out.Relations) > 0 {
+		result.WriteString("\nWaiting on the same object:\n")
+		for _, r := range out.Relations {
+			fmt.Fprintf(&result, "  %s on %s: %s\n      %s\n", r.State, r.Object, joinIDs(r.Waiters), r.Note)
+		}
+	}
+
+	type site struct{ state, location string }
+	var sites []site
+	members := make(map[site][]int)
+	for _, bt := range out.Threads {
+		if bt.State == stateRuntime {
+			continue
+		}
+		s := site{state: bt.State}
+		if bt.Location != nil {
+			s.location = frameLocation(*bt.Location)
+		}
+		if members[s] == nil {
+			sites = append(sites, s)
+		}
+		members[s] = append(members[s], bt.ID)
+	}
+	slices.SortStableFunc(sites, func(a, b site) int { return len(members[b]) - len(members[a]) })
+	if len(sites) > 0 {
+		fmt.Fprintf(&result, "\n%s by state and location:\n", strings.ToUpper(noun[:1])+noun[1:])
+	}
+	for _, s := range sites {
+		fmt.Fprintf(&result, "  %s (%d): %s\n", s.state, len(members[s]), joinIDs(members[s]))
+		if s.location != "" {
+			fmt.Fprintf(&result, "      at %s\n", s.location)
+		}
+	}
+	if counts[stateRuntime] > 0 {
+		fmt.Fprintf(&result, "\n%d Go runtime goroutines not shown.\n", counts[stateRuntime])
+	}
+	return result.String()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestClassifyThread(t *testing.T) {
+	tests := []struct {
+		frames   []string
+		state    string
+		location string
+	}{
+		{[]string{"runtime.gopark", "runtime.chanrecv", "runtime.chanrecv1", "main.consume", "main.main"}, "chan receive", "main.consume"},
+		{[]string{"runtime.gopark", "runtime.goparkunlock", "runtime.semacquire1", "sync.runtime_SemacquireMutex", "sync.(*Mutex).lockSlow", "sync.(*Mutex).Lock", "main.locker"}, "mutex", "main.locker"},
+		{[]string{"runtime.gopark", "runtime.netpollblock", "internal/poll.runtime_pollWait", "internal/poll.(*pollDesc).wait", "net.(*netFD).accept", "main.serve"}, "netpoll", "net.(*netFD).accept"},
+		{[]string{"runtime.gopark", "runtime.goparkunlock", "runtime.forcegchelper", "runtime.goexit"}, "runtime", ""},
+		{[]string{"runtime.gopark", "main.custom"}, "parked", "main.custom"},
+		{[]string{"main.main", "runtime.main"}, "running", "main.main"},
+		{[]string{"__futex_abstimed_wait_common", "__lll_lock_wait", "___pthread_mutex_lock", "transfer", "worker"}, "pthread mutex", "transfer"},
+		{[]string{"__futex_abstimed_wait_common", "pthread_cond_wait@@GLIBC_2.3.2", "consume"}, "pthread condvar", "consume"},
+		{nil, "running", ""},
+	}
+	for _, tt := range tests {
+		th := Thread{ID: 1}
+		for i, name := range tt.frames {
+			th.Frames = append(th.Frames, StackFrame{ID: i, Name: name})
+		}
+		bt, _, _ := classifyThread(th)
+		location := ""
+		if bt.Location != nil {
+			location = bt.Location.Name
+		}
+		if bt.State != tt.state || location != tt.location {
+			t.Errorf("classifyThread(%v) = %q at %q, want %q at %q", tt.frames, bt.State, location, tt.state, tt.location)
+		}
+	}
+}
+
+func TestWaitRelations(t *testing.T) {
+	threads := []Thread{
+		{ID: 1, Name: "Thread 0x7f01 (LWP 100)"},
+		{ID: 2, Name: "Thread 0x7f02 (LWP 101)"},
+	}
+	blocked := []BlockedThread{
+		{ID: 1, State: "pthread mutex", Object: "0x4040", OwnerLWP: 101},
+		{ID: 2, State: "pthread mutex", Object: "0x4080", OwnerLWP: 100},
+	}
+	relations := waitRelations(blocked, threads)
+	if len(relations) != 2 {
+		t.Fatalf("got %d relations, want 2: %+v", len(relations), relations)
+	}
+	if r := relations[0]; r.Owner != 2 || !slices.Equal(r.Waiters, []int{1}) || !strings.Contains(r.Note, "deadlock") {
+		t.Errorf("relation for 0x4040 = %+v, want thread 1 waiting on thread 2, in a deadlock", r)
+	}
+	if !isDeadlocked(blocked) {
+		t.Error("isDeadlocked = false for two threads waiting on each other's mutex")
+	}
+
+	blocked = append(blocked, BlockedThread{ID: 3, State: "sleep"})
+	if isDeadlocked(blocked) {
+		t.Error("isDeadlocked = true with a sleeping thread that will wake up")
+	}
+
+	relations = waitRelations([]BlockedThread{
+		{ID: 5, State: "chan receive", Object: "0xc000"},
+		{ID: 6, State: "chan send", Object: "0xc000"},
+		{ID: 7, State: "chan send", Object: "0xd000"},
+	}, nil)
+	if len(relations) != 3 || strings.Contains(relations[0].Note, "no goroutine") || !strings.Contains(relations[2].Note, "no goroutine is blocked receiving") {
+		t.Errorf("got channel relations %+v, want only 0xd000 without a peer", relations)
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+
+	"github.com/google/go-dap"
+)
+
+// breakpointRegistry tracks every breakpoint set during a debug session.
+//
+// DAP's setBreakpoints, setFunctionBreakpoints, setInstructionBreakpoints,
+// setDataBreakpoints and setExceptionBreakpoints requests all replace the
+// adapter's existing set (per source file for setBreakpoints), so sending a
+// single new entry silently drops the others. The registry is the source
+// of truth: every update sends the full merged set for the affected kind,
+// and the whole registry is replayed after a restart.
+type breakpointRegistry struct {
+	sources      map[string][]dap.SourceBreakpoint // keyed by file path, sorted by line
+	functions    []dap.FunctionBreakpoint
+	instructions []dap.InstructionBreakpoint
+	data         []dataWatch
+	exceptions   []dap.ExceptionFilterOptions // enabled exception filters, with optional conditions
+}
+
+// dataWatch is a data breakpoint set by 'watch', with what it watches.
+type dataWatch struct {
+	dap.DataBreakpoint
+	name        string // variable name or expression given to 'watch'
+	description string // the adapter's description of the watched data
+	canPersist  bool   // whether the dataId stays valid across a restart
+}
+
+// setSource adds a source breakpoint to file, replacing any existing
+// breakpoint on the same line.
+func (r *breakpointRegistry) setSource(file string, bp dap.SourceBreakpoint) {
+	if r.sources == nil {
+		r.sources = make(map[string][]dap.SourceBreakpoint)
+	}
+	bps := r.sources[file]
+	i := sort.Search(len(bps), func(i int) bool { return bps[i].Line >= bp.Line })
+	if i < len(bps) && bps[i].Line == bp.Line {
+		bps[i] = bp
+	} else {
+		bps = slices.Insert(bps, i, bp)
+	}
+	r.sources[file] = bps
+}
+
+// removeSource removes the breakpoint on line in file, if any.
+func (r *breakpointRegistry) removeSource(file string, line int) {
+	bps := slices.DeleteFunc(r.sources[file], func(bp dap.SourceBreakpoint) bool { return bp.Line == line })
+	if len(bps) == 0 {
+		delete(r.sources, file)
+		return
+	}
+	r.sources[file] = bps
+}
+
+// replaceSources replaces the breakpoints in file with bps, which must be
+// sorted by line as sourceBreakpoints returns them.
+func (r *breakpointRegistry) replaceSources(file string, bps []dap.SourceBreakpoint) {
+	if len(bps) == 0 {
+		delete(r.sources, file)
+		return
+	}
+	r.sources[file] = bps
+}
+
+// clearSource removes all breakpoints in file.
+func (r *breakpointRegistry) clearSource(file string) {
+	delete(r.sources, file)
+}
+
+// sourceBreakpoints returns a copy of the breakpoints registered for file.
+func (r *breakpointRegistry) sourceBreakpoints(file string) []dap.SourceBreakpoint {
+	return slices.Clone(r.sources[file])
+}
+
+// files returns the paths of all files that have breakpoints, sorted.
+func (r *breakpointRegistry) files() []string {
+	files := make([]string, 0, len(r.sources))
+	for f := range r.sources {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// setFunction adds a function breakpoint, replacing any existing breakpoint
+// on the same function.
+func (r *breakpointRegistry) setFunction(bp dap.FunctionBreakpoint) {
+	for i := range r.functions {
+		if r.functions[i].Name == bp.Name {
+			r.functions[i] = bp
+			return
+		}
+	}
+	r.functions = append(r.functions, bp)
+}
+
+// removeFunction removes the breakpoint on the named function, if any.
+func (r *breakpointRegistry) removeFunction(name string) {
+	r.functions = slices.DeleteFunc(r.functions, func(bp dap.FunctionBreakpoint) bool { return bp.Name == name })
+}
+
+// functionBreakpoints returns a copy of the registered function breakpoints.
+func (r *breakpointRegistry) functionBreakpoints() []dap.FunctionBreakpoint {
+	return slices.Clone(r.functions)
+}
+
+// setInstruction adds an instruction breakpoint, replacing any existing
+// breakpoint on the same instruction reference and offset.
+func (r *breakpointRegistry) setInstruction(bp dap.InstructionBreakpoint) {
+	for i := range r.instructions {
+		if r.instructions[i].InstructionReference == bp.InstructionReference && r.instructions[i].Offset == bp.Offset {
+			r.instructions[i] = bp
+			return
+		}
+	}
+	r.instructions = append(r.instructions, bp)
+}
+
+// removeInstruction removes the breakpoint on the given instruction
+// reference and offset, if any.
+func (r *breakpointRegistry) removeInstruction(ref string, offset int) {
+	r.instructions = slices.DeleteFunc(r.instructions, func(bp dap.InstructionBreakpoint) bool {
+		return bp.InstructionReference == ref && bp.Offset == offset
+	})
+}
+
+// instructionBreakpoints returns a copy of the registered instruction breakpoints.
+func (r *breakpointRegistry) instructionBreakpoints() []dap.InstructionBreakpoint {
+	return slices.Clone(r.instructions)
+}
+
+// setData adds a data breakpoint, replacing any existing watch on the same
+// dataId.
+func (r *breakpointRegistry) setData(w dataWatch) {
+	for i := range r.data {
+		if r.data[i].DataId == w.DataId {
+			r.data[i] = w
+			return
+		}
+	}
+	r.data = append(r.data, w)
+}
+
+// removeData removes the watches on the named variable and reports whether
+// there were any.
+func (r *breakpointRegistry) removeData(name string) bool {
+	n := len(r.data)
+	r.data = slices.DeleteFunc(r.data, func(w dataWatch) bool { return w.name == name })
+	return len(r.data) < n
+}
+
+// dropTransientData removes the watches whose dataId does not survive a
+// restart, and returns them.
+func (r *breakpointRegistry) dropTransientData() []dataWatch {
+	var dropped []dataWatch
+	r.data = slices.DeleteFunc(r.data, func(w dataWatch) bool {
+		if !w.canPersist {
+			dropped = append(dropped, w)
+		}
+		return !w.canPersist
+	})
+	return dropped
+}
+
+// watches returns a copy of the registered data breakpoints.
+func (r *breakpointRegistry) watches() []dataWatch {
+	return slices.Clone(r.data)
+}
+
+// dataBreakpoints returns the registered data breakpoints as sent to the
+// adapter.
+func (r *breakpointRegistry) dataBreakpoints() []dap.DataBreakpoint {
+	bps := make([]dap.DataBreakpoint, len(r.data))
+	for i, w := range r.data {
+		bps[i] = w.DataBreakpoint
+	}
+	return bps
+}
+
+// setException enables an exception filter, replacing the condition of a
+// filter that is already enabled.
+func (r *breakpointRegistry) setException(opt dap.ExceptionFilterOptions) {
+	for i := range r.exceptions {
+		if r.exceptions[i].FilterId == opt.FilterId {
+			r.exceptions[i] = opt
+			return
+		}
+	}
+	r.exceptions = append(r.exceptions, opt)
+}
+
+// removeException disables an exception filter, if enabled.
+func (r *breakpointRegistry) removeException(filter string) {
+	r.exceptions = slices.DeleteFunc(r.exceptions, func(opt dap.ExceptionFilterOptions) bool { return opt.FilterId == filter })
+}
+
+// exceptionFilters returns a copy of the enabled exception filters.
+func (r *breakpointRegistry) exceptionFilters() []dap.ExceptionFilterOptions {
+	return slices.Clone(r.exceptions)
+}
+
+// exceptionArgs splits the enabled exception filters into the filters and
+// filterOptions arguments of setExceptionBreakpoints. Filters with a
+// condition go in filterOptions when withOptions is set; otherwise the
+// condition is dropped. ids lists the filter IDs in the order the adapter
+// reports their breakpoints: filters first, then filterOptions.
+func (r *breakpointRegistry) exceptionArgs(withOptions bool) (filters []string, options []dap.ExceptionFilterOptions, ids []string) {
+	filters = []string{}
+	for _, opt := range r.exceptions {
+		if withOptions && opt.Condition != "" {
+			options = append(options, opt)
+		} else {
+			filters = append(filters, opt.FilterId)
+		}
+	}
+	ids = slices.Clone(filters)
+	for _, opt := range options {
+		ids = append(ids, opt.FilterId)
+	}
+	return filters, options, ids
+}
+
+// clear removes all breakpoints and watches from the registry. Exception
+// filters are kept: 'exception-breakpoints' manages them, and the session
+// starts with the adapter's defaults enabled.
+func (r *breakpointRegistry) clear() {
+	r.sources = nil
+	r.functions = nil
+	r.instructions = nil
+	r.data = nil
+}
+
+// unsupportedBreakpointOptions returns the JSON names of the optional
+// breakpoint fields whose capability the adapter did not report.
+func unsupportedBreakpointOptions(caps dap.Capabilities) []string {
+	var fields []string
+	if !caps.SupportsConditionalBreakpoints {
+		fields = append(fields, "condition")
+	}
+	if !caps.SupportsHitConditionalBreakpoints {
+		fields = append(fields, "hitCondition")
+	}
+	if !caps.SupportsLogPoints {
+		fields = append(fields, "logMessage")
+	}
+	return fields
+}
+
+// syncSourceBreakpoints sends the registry's full breakpoint set for file to
+// the adapter, plus any extra (unregistered) breakpoints such as a temporary
+// run-to-cursor target. The returned breakpoints are in request order:
+// registered breakpoints first, then extra.
+func (ds *debuggerSession) syncSourceBreakpoints(file string, extra ...dap.SourceBreakpoint) ([]dap.Breakpoint, error) {
+	bps := append(ds.breakpoints.sourceBreakpoints(file), extra...)
+	seq, err := ds.client.SetBreakpointsRequest(file, bps)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := readTypedResponse[*dap.SetBreakpointsResponse](ds.client, seq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set breakpoints in %s: %w", file, err)
+	}
+	return resp.Body.Breakpoints, nil
+}
+
+// syncFunctionBreakpoints sends the registry's full function breakpoint set
+// to the adapter, plus any extra (unregistered) breakpoints. The returned
+// breakpoints are in request order: registered breakpoints first, then extra.
+func (ds *debuggerSession) syncFunctionBreakpoints(extra ...dap.FunctionBreakpoint) ([]dap.Breakpoint, error) {
+	bps := append(ds.breakpoints.functionBreakpoints(), extra...)
+	seq, err := ds.client.SetFunctionBreakpointsRequest(bps)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := readTypedResponse[*dap.SetFunctionBreakpointsResponse](ds.client, seq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set function breakpoints: %w", err)
+	}
+	return resp.Body.Breakpoints, nil
+}
+
+// syncInstructionBreakpoints sends the registry's full instruction breakpoint
+// set to the adapter.
+func (ds *debuggerSession) syncInstructionBreakpoints() ([]dap.Breakpoint, error) {
+	seq, err := ds.client.SetInstructionBreakpointsRequest(ds.breakpoints.instructionBreakpoints())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := readTypedResponse[*dap.SetInstructionBreakpointsResponse](ds.client, seq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set instruction breakpoints: %w", err)
+	}
+	return resp.Body.Breakpoints, nil
+}
+
+// syncDataBreakpoints sends the registry's full data breakpoint set to the
+// adapter.
+func (ds *debuggerSession) syncDataBreakpoints() ([]dap.Breakpoint, error) {
+	seq, err := ds.client.SetDataBreakpointsRequest(ds.breakpoints.dataBreakpoints())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := readTypedResponse[*dap.SetDataBreakpointsResponse](ds.client, seq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set data breakpoints: %w", err)
+	}
+	return resp.Body.Breakpoints, nil
+}
+
+// syncExceptionBreakpoints sends the enabled exception filters to the
+// adapter. The returned filter IDs and breakpoints are in matching order;
+// adapters may omit the breakpoints.
+func (ds *debuggerSession) syncExceptionBreakpoints() ([]string, []dap.Breakpoint, error) {
+	filters, options, ids := ds.breakpoints.exceptionArgs(ds.capabilities.SupportsExceptionFilterOptions)
+	seq, err := ds.client.SetExceptionBreakpointsRequest(filters, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := readTypedResponse[*dap.SetExceptionBreakpointsResponse](ds.client, seq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to set exception breakpoints: %w", err)
+	}
+	return ids, resp.Body.Breakpoints, nil
+}
+
+// applyBreakpoints sends every registered breakpoint to the adapter. It is
+// used after a restart, when the adapter's breakpoint state can no longer be
+// trusted to match the registry. Data breakpoints whose dataId does not
+// persist across restarts are dropped; they must be set again with 'watch'.
+func (ds *debuggerSession) applyBreakpoints() error {
+	for _, file := range ds.breakpoints.files() {
+		if _, err := ds.syncSourceBreakpoints(file); err != nil {
+			return err
+		}
+	}
+	if len(ds.breakpoints.functions) > 0 {
+		if _, err := ds.syncFunctionBreakpoints(); err != nil {
+			return err
+		}
+	}
+	if len(ds.breakpoints.instructions) > 0 && ds.capabilities.SupportsInstructionBreakpoints {
+		if _, err := ds.syncInstructionBreakpoints(); err != nil {
+			return err
+		}
+	}
+	for _, w := range ds.breakpoints.dropTransientData() {
+		log.Printf("dropping watch on %s: its data breakpoint does not persist across restarts", w.name)
+	}
+	if len(ds.breakpoints.data) > 0 && ds.capabilities.SupportsDataBreakpoints {
+		if _, err := ds.syncDataBreakpoints(); err != nil {
+			return err
+		}
+	}
+	if len(ds.breakpoints.exceptions) > 0 {
+		if _, _, err := ds.syncExceptionBreakpoints(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearAllBreakpoints removes every registered breakpoint and watch from
+// the adapter and the registry. Exception filters stay enabled.
+func (ds *debuggerSession) clearAllBreakpoints() error {
+	files := ds.breakpoints.files()
+	hadInstructions := len(ds.breakpoints.instructions) > 0
+	hadData := len(ds.breakpoints.data) > 0
+	ds.breakpoints.clear()
+	for _, file := range files {
+		if _, err := ds.syncSourceBreakpoints(file); err != nil {
+			return err
+		}
+	}
+	if _, err := ds.syncFunctionBreakpoints(); err != nil {
+		return err
+	}
+	if hadInstructions && ds.capabilities.SupportsInstructionBreakpoints {
+		if _, err := ds.syncInstructionBreakpoints(); err != nil {
+			return err
+		}
+	}
+	if hadData && ds.capabilities.SupportsDataBreakpoints {
+		if _, err := ds.syncDataBreakpoints(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
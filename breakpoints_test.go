@@ -0,0 +1,272 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-dap"
+)
+
+func TestBreakpointRegistrySource(t *testing.T) {
+	var r breakpointRegistry
+
+	r.setSource("/a.go", dap.SourceBreakpoint{Line: 20})
+	r.setSource("/a.go", dap.SourceBreakpoint{Line: 10})
+	r.setSource("/b.go", dap.SourceBreakpoint{Line: 5})
+	// Setting the same line again replaces rather than duplicates.
+	r.setSource("/a.go", dap.SourceBreakpoint{Line: 20})
+
+	var lines []int
+	for _, bp := range r.sourceBreakpoints("/a.go") {
+		lines = append(lines, bp.Line)
+	}
+	if !slices.Equal(lines, []int{10, 20}) {
+		t.Errorf("expected lines [10 20] in /a.go, got: %v", lines)
+	}
+	if files := r.files(); !slices.Equal(files, []string{"/a.go", "/b.go"}) {
+		t.Errorf("expected files [/a.go /b.go], got: %v", files)
+	}
+
+	r.removeSource("/a.go", 10)
+	if bps := r.sourceBreakpoints("/a.go"); len(bps) != 1 || bps[0].Line != 20 {
+		t.Errorf("expected only line 20 left in /a.go, got: %v", bps)
+	}
+
+	r.removeSource("/a.go", 20)
+	if files := r.files(); !slices.Equal(files, []string{"/b.go"}) {
+		t.Errorf("expected /a.go to be dropped once empty, got: %v", files)
+	}
+
+	prev := r.sourceBreakpoints("/b.go")
+	r.setSource("/b.go", dap.SourceBreakpoint{Line: 6})
+	r.replaceSources("/b.go", prev)
+	if bps := r.sourceBreakpoints("/b.go"); len(bps) != 1 || bps[0].Line != 5 {
+		t.Errorf("expected only line 5 in /b.go after replaceSources, got: %v", bps)
+	}
+
+	r.clearSource("/b.go")
+	if files := r.files(); len(files) != 0 {
+		t.Errorf("expected no files after clearSource, got: %v", files)
+	}
+}
+
+func TestBreakpointRegistryFunctionsAndInstructions(t *testing.T) {
+	var r breakpointRegistry
+
+	r.setFunction(dap.FunctionBreakpoint{Name: "main.main"})
+	r.setFunction(dap.FunctionBreakpoint{Name: "main.run"})
+	r.setFunction(dap.FunctionBreakpoint{Name: "main.main"})
+	if fns := r.functionBreakpoints(); len(fns) != 2 {
+		t.Errorf("expected 2 function breakpoints, got: %v", fns)
+	}
+	r.removeFunction("main.main")
+	if fns := r.functionBreakpoints(); len(fns) != 1 || fns[0].Name != "main.run" {
+		t.Errorf("expected only main.run left, got: %v", fns)
+	}
+
+	r.setInstruction(dap.InstructionBreakpoint{InstructionReference: "0x1000"})
+	r.setInstruction(dap.InstructionBreakpoint{InstructionReference: "0x1000", Offset: 4})
+	r.setInstruction(dap.InstructionBreakpoint{InstructionReference: "0x1000"})
+	if ins := r.instructionBreakpoints(); len(ins) != 2 {
+		t.Errorf("expected 2 instruction breakpoints, got: %v", ins)
+	}
+	r.removeInstruction("0x1000", 4)
+	if ins := r.instructionBreakpoints(); len(ins) != 1 || ins[0].Offset != 0 {
+		t.Errorf("expected only offset 0 left, got: %v", ins)
+	}
+
+	r.setSource("/a.go", dap.SourceBreakpoint{Line: 1})
+	r.clear()
+	if len(r.files()) != 0 || len(r.functionBreakpoints()) != 0 || len(r.instructionBreakpoints()) != 0 {
+		t.Error("expected registry to be empty after clear")
+	}
+}
+
+func TestBreakpointRegistryReturnsCopies(t *testing.T) {
+	var r breakpointRegistry
+	r.setSource("/a.go", dap.SourceBreakpoint{Line: 1})
+
+	bps := r.sourceBreakpoints("/a.go")
+	bps[0].Line = 99
+	_ = append(bps, dap.SourceBreakpoint{Line: 2})
+
+	if got := r.sourceBreakpoints("/a.go"); len(got) != 1 || got[0].Line != 1 {
+		t.Errorf("expected registry to be unaffected by caller mutation, got: %v", got)
+	}
+}
+
+func TestBreakpointSpecValidate(t *testing.T) {
+	all := dap.Capabilities{
+		SupportsConditionalBreakpoints:    true,
+		SupportsHitConditionalBreakpoints: true,
+		SupportsLogPoints:                 true,
+	}
+
+	tests := []struct {
+		name    string
+		spec    BreakpointSpec
+		caps    dap.Capabilities
+		wantErr string
+	}{
+		{"plain", BreakpointSpec{File: "/a.go", Line: 1}, dap.Capabilities{}, ""},
+		{"condition supported", BreakpointSpec{File: "/a.go", Line: 1, Condition: "i == 1"}, all, ""},
+		{"condition unsupported", BreakpointSpec{File: "/a.go", Line: 1, Condition: "i == 1"}, dap.Capabilities{}, "condition"},
+		{"hitCondition unsupported", BreakpointSpec{Function: "main.f", HitCondition: "5"}, dap.Capabilities{}, "hitCondition"},
+		{"logMessage unsupported", BreakpointSpec{File: "/a.go", Line: 1, LogMessage: "x"}, dap.Capabilities{}, "logMessage"},
+		{"logMessage on function", BreakpointSpec{Function: "main.f", LogMessage: "x"}, all, "file+line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.validate(tt.caps)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error mentioning %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBreakpointInputSchemaGating(t *testing.T) {
+	schema := inputSchemaWithout[BreakpointToolParams](unsupportedBreakpointOptions(dap.Capabilities{
+		SupportsConditionalBreakpoints: true,
+	})...)
+	if _, ok := schema.Properties["condition"]; !ok {
+		t.Error("expected 'condition' to be advertised when supported")
+	}
+	for _, field := range []string{"hitCondition", "logMessage"} {
+		if _, ok := schema.Properties[field]; ok {
+			t.Errorf("did not expect %q to be advertised when unsupported", field)
+		}
+	}
+	for _, field := range []string{"file", "line", "function"} {
+		if _, ok := schema.Properties[field]; !ok {
+			t.Errorf("expected %q to always be advertised", field)
+		}
+	}
+}
+
+func TestBreakpointRegistryData(t *testing.T) {
+	var r breakpointRegistry
+
+	r.setData(dataWatch{DataBreakpoint: dap.DataBreakpoint{DataId: "1", AccessType: "write"}, name: "x", canPersist: true})
+	r.setData(dataWatch{DataBreakpoint: dap.DataBreakpoint{DataId: "2", AccessType: "write"}, name: "y"})
+	// Watching the same dataId again replaces the access type.
+	r.setData(dataWatch{DataBreakpoint: dap.DataBreakpoint{DataId: "1", AccessType: "read"}, name: "x", canPersist: true})
+
+	bps := r.dataBreakpoints()
+	if len(bps) != 2 || bps[0].DataId != "1" || bps[0].AccessType != "read" || bps[1].DataId != "2" {
+		t.Errorf("unexpected data breakpoints: %v", bps)
+	}
+
+	dropped := r.dropTransientData()
+	if len(dropped) != 1 || dropped[0].name != "y" {
+		t.Errorf("expected y to be dropped as transient, got: %v", dropped)
+	}
+	if ws := r.watches(); len(ws) != 1 || ws[0].name != "x" {
+		t.Errorf("expected only x left, got: %v", ws)
+	}
+
+	if r.removeData("y") {
+		t.Error("removeData reported removing a watch that was already dropped")
+	}
+	if !r.removeData("x") || len(r.data) != 0 {
+		t.Errorf("expected x to be removed, got: %v", r.data)
+	}
+}
+
+func TestBreakpointRegistryExceptions(t *testing.T) {
+	var r breakpointRegistry
+
+	r.setException(dap.ExceptionFilterOptions{FilterId: "raised", Condition: "x > 1"})
+	r.setException(dap.ExceptionFilterOptions{FilterId: "uncaught"})
+	r.setException(dap.ExceptionFilterOptions{FilterId: "raised", Condition: "x > 2"})
+	if got := r.exceptionFilters(); len(got) != 2 || got[0].Condition != "x > 2" {
+		t.Errorf("expected raised (x > 2) and uncaught, got: %v", got)
+	}
+
+	filters, options, ids := r.exceptionArgs(true)
+	if !slices.Equal(filters, []string{"uncaught"}) || len(options) != 1 || options[0].FilterId != "raised" {
+		t.Errorf("exceptionArgs(true) = %v, %v", filters, options)
+	}
+	// Breakpoints are reported for filters first, then filterOptions.
+	if !slices.Equal(ids, []string{"uncaught", "raised"}) {
+		t.Errorf("exceptionArgs(true) ids = %v", ids)
+	}
+
+	filters, options, _ = r.exceptionArgs(false)
+	if !slices.Equal(filters, []string{"raised", "uncaught"}) || options != nil {
+		t.Errorf("exceptionArgs(false) = %v, %v", filters, options)
+	}
+
+	r.removeException("raised")
+	filters, _, _ = r.exceptionArgs(true)
+	if !slices.Equal(filters, []string{"uncaught"}) {
+		t.Errorf("expected only uncaught after removal, got: %v", filters)
+	}
+
+	// Clearing breakpoints leaves exception filters alone.
+	r.setSource("/a.go", dap.SourceBreakpoint{Line: 1})
+	r.clear()
+	if got := r.exceptionFilters(); len(got) != 1 || got[0].FilterId != "uncaught" {
+		t.Errorf("expected uncaught to stay enabled after clear, got: %v", got)
+	}
+}
+
+func TestClearAllBreakpointsKeepsExceptionFilters(t *testing.T) {
+	client, requests, serverWriter := newPipeClient(t)
+	ds := &debuggerSession{client: client}
+	ds.breakpoints.setSource("/a.go", dap.SourceBreakpoint{Line: 1})
+	ds.breakpoints.setFunction(dap.FunctionBreakpoint{Name: "main.main"})
+	ds.breakpoints.setException(dap.ExceptionFilterOptions{FilterId: "uncaught"})
+
+	// Answer every request with an empty success, noting its command.
+	var commands []string
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			var msg dap.Message
+			select {
+			case msg = <-requests:
+			case <-done:
+				return
+			}
+			req := msg.(dap.RequestMessage).GetRequest()
+			commands = append(commands, req.Command)
+			var resp dap.ResponseMessage
+			switch req.Command {
+			case "setBreakpoints":
+				resp = &dap.SetBreakpointsResponse{}
+			case "setFunctionBreakpoints":
+				resp = &dap.SetFunctionBreakpointsResponse{}
+			default:
+				resp = &dap.SetExceptionBreakpointsResponse{}
+			}
+			r := resp.GetResponse()
+			r.Type = "response"
+			r.Command = req.Command
+			r.RequestSeq = req.Seq
+			r.Success = true
+			dap.WriteProtocolMessage(serverWriter, resp)
+		}
+	}()
+
+	if err := ds.clearAllBreakpoints(); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(commands, "setExceptionBreakpoints") {
+		t.Errorf("expected exception filters not to be sent, got requests: %v", commands)
+	}
+	if len(ds.breakpoints.files()) != 0 || len(ds.breakpoints.functionBreakpoints()) != 0 {
+		t.Error("expected breakpoints to be cleared")
+	}
+	if got := ds.breakpoints.exceptionFilters(); len(got) != 1 || got[0].FilterId != "uncaught" {
+		t.Errorf("expected uncaught to stay enabled, got: %v", got)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config holds the server settings. They come from command-line flags and
+// an optional YAML or JSON config file; flags take precedence over the file.
+type config struct {
+	Transport    string            `yaml:"transport"`    // "stdio", "sse", or "http"
+	Listen       string            `yaml:"listen"`       // address for the sse and http transports
+	Token        string            `yaml:"token"`        // bearer token for the sse and http transports
+	LogFile      string            `yaml:"logFile"`      // server log path
+	LogLevel     string            `yaml:"logLevel"`     // "off", "info", or "debug"
+	Debugger     string            `yaml:"debugger"`     // debugger used when 'debug' names none
+	DlvPath      string            `yaml:"dlvPath"`      // dlv binary
+	GDBPath      string            `yaml:"gdbPath"`      // gdb binary; empty to look it up in PATH
+	MaxFrames    int               `yaml:"maxFrames"`    // stack frames fetched when a tool call gives no maximum
+	FullContext  bool              `yaml:"fullContext"`  // stops return the full context unless a tool call asks otherwise
+	AllowedModes []string          `yaml:"allowedModes"` // 'debug' modes clients may use; empty allows all
+	BuildFlags   map[string]string `yaml:"buildFlags"`   // flags for building programs, by language
+}
+
+// debugModes lists the modes of the 'debug' tool.
+var debugModes = []string{"source", "binary", "core", "attach", "remote", "record", "replay"}
+
+// defaultConfig returns the settings used when neither a flag nor the config
+// file sets them.
+func defaultConfig() *config {
+	return &config{
+		Transport: "stdio",
+		Listen:    "localhost:8080",
+		LogFile:   filepath.Join(os.TempDir(), "mcp-dap-server.log"),
+		LogLevel:  "debug",
+		Debugger:  "delve",
+		DlvPath:   "dlv",
+		MaxFrames: defaultMaxFrames,
+	}
+}
+
+// loadConfig defines the server's flags on fs, parses args with them, and
+// reads the config file named by -config or $MCP_DAP_SERVER_CONFIG. The
+// token defaults to $MCP_DAP_SERVER_TOKEN.
+func loadConfig(fs *flag.FlagSet, args []string) (*config, error) {
+	cfg := defaultConfig()
+	configPath := fs.String("config", "", "YAML or JSON file with server settings; flags override it (default: $MCP_DAP_SERVER_CONFIG)")
+	fs.StringVar(&cfg.Transport, "transport", cfg.Transport, "MCP transport: 'stdio', 'sse', or 'http' (streamable HTTP)")
+	fs.StringVar(&cfg.Listen, "listen", cfg.Listen, "address to listen on for the sse and http transports")
+	fs.StringVar(&cfg.Token, "token", "", "bearer token that sse and http clients must send (default: $MCP_DAP_SERVER_TOKEN; none if both are empty)")
+	fs.StringVar(&cfg.LogFile, "log-file", cfg.LogFile, "server log file")
+	fs.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "'off', 'info' (server log only), or 'debug' (also Delve's DAP traffic)")
+	fs.StringVar(&cfg.Debugger, "debugger", cfg.Debugger, "debugger used when 'debug' names none: 'delve', 'gdb', 'lldb', or 'debugpy'")
+	fs.StringVar(&cfg.DlvPath, "dlv-path", cfg.DlvPath, "dlv binary")
+	fs.StringVar(&cfg.GDBPath, "gdb-path", "", "gdb binary (default: gdb in PATH)")
+	fs.IntVar(&cfg.MaxFrames, "max-frames", cfg.MaxFrames, "stack frames fetched when a tool call gives no maximum")
+	fs.BoolVar(&cfg.FullContext, "full-context", false, "return the stack trace and variables with every stop unless a tool call asks for a compact summary")
+	fs.Var((*listFlag)(&cfg.AllowedModes), "allowed-modes", "comma-separated `modes` clients may start 'debug' sessions in (default: all)")
+	fs.Var((*buildFlagsFlag)(&cfg.BuildFlags), "build-flags", "`language=flags` to build that language's programs with, e.g. 'go=-tags=integration'; may be repeated")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	path := *configPath
+	if path == "" {
+		path = os.Getenv("MCP_DAP_SERVER_CONFIG")
+	}
+	if path != "" {
+		if err := cfg.load(path); err != nil {
+			return nil, err
+		}
+		// Parse the flags again so that they override the file.
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("MCP_DAP_SERVER_TOKEN")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// load reads the settings in the config file at path over c's. JSON is
+// valid YAML, so both formats are read as YAML.
+func (c *config) load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// validate checks settings that would otherwise only fail once a client
+// uses them.
+func (c *config) validate() error {
+	switch c.Transport {
+	case "stdio", "sse", "http":
+	default:
+		return fmt.Errorf("invalid transport: %s (must be 'stdio', 'sse', or 'http')", c.Transport)
+	}
+	switch c.LogLevel {
+	case "off", "info", "debug":
+	default:
+		return fmt.Errorf("invalid log level: %s (must be 'off', 'info', or 'debug')", c.LogLevel)
+	}
+	switch c.Debugger {
+	case "delve", "gdb", "lldb", "debugpy":
+	default:
+		return fmt.Errorf("unsupported debugger: %s (must be 'delve', 'gdb', 'lldb', or 'debugpy')", c.Debugger)
+	}
+	if c.MaxFrames < 1 {
+		return fmt.Errorf("maxFrames must be at least 1, got %d", c.MaxFrames)
+	}
+	for _, mode := range c.AllowedModes {
+		if !slices.Contains(debugModes, mode) {
+			return fmt.Errorf("invalid allowed mode: %s (must be one of %s)", mode, strings.Join(debugModes, ", "))
+		}
+	}
+	for lang := range c.BuildFlags {
+		// Other debuggers debug programs built beforehand.
+		if lang != "go" {
+			return fmt.Errorf("unsupported build flags language: %s (only 'go' programs are built by the server)", lang)
+		}
+	}
+	return nil
+}
+
+// modeAllowed reports whether clients may start sessions in mode.
+func (c *config) modeAllowed(mode string) bool {
+	return len(c.AllowedModes) == 0 || slices.Contains(c.AllowedModes, mode)
+}
+
+// listFlag is a flag holding a comma-separated list.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(s string) error {
+	*f = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*f = append(*f, item)
+		}
+	}
+	return nil
+}
+
+// buildFlagsFlag is a repeatable flag setting build flags by language, as
+// 'language=flags'.
+type buildFlagsFlag map[string]string
+
+func (f *buildFlagsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	var pairs []string
+	for lang, flags := range *f {
+		pairs = append(pairs, lang+"="+flags)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, " ")
+}
+
+func (f *buildFlagsFlag) Set(s string) error {
+	lang, flags, ok := strings.Cut(s, "=")
+	if !ok || lang == "" {
+		return fmt.Errorf("must be 'language=flags', e.g. 'go=-tags=integration'")
+	}
+	if *f == nil {
+		*f = make(buildFlagsFlag)
+	}
+	(*f)[lang] = flags
+	return nil
+}
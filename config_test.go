@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testLoadConfig loads the config from args with a fresh flag set.
+func testLoadConfig(args ...string) (*config, error) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return loadConfig(fs, args)
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	t.Setenv("MCP_DAP_SERVER_CONFIG", "")
+	t.Setenv("MCP_DAP_SERVER_TOKEN", "env-token")
+	cfg, err := testLoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := defaultConfig()
+	want.Token = "env-token"
+	if cfg.Transport != want.Transport || cfg.LogLevel != want.LogLevel || cfg.Debugger != want.Debugger || cfg.MaxFrames != want.MaxFrames || cfg.Token != want.Token || cfg.FullContext || len(cfg.AllowedModes) != 0 {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+	if !cfg.modeAllowed("record") {
+		t.Error("modeAllowed(record) = false, want every mode allowed by default")
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(yamlPath, []byte(`
+debugger: gdb
+dlvPath: /opt/dlv-1.25/dlv
+maxFrames: 50
+fullContext: true
+allowedModes: [source, binary]
+buildFlags:
+  go: -tags=integration
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jsonPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"logLevel": "info", "gdbPath": "/usr/local/bin/gdb"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		cfg, err := testLoadConfig("-config", yamlPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Debugger != "gdb" || cfg.DlvPath != "/opt/dlv-1.25/dlv" || cfg.MaxFrames != 50 || !cfg.FullContext || cfg.BuildFlags["go"] != "-tags=integration" {
+			t.Errorf("got %+v, want the file's settings", cfg)
+		}
+		if !cfg.modeAllowed("binary") || cfg.modeAllowed("attach") {
+			t.Errorf("allowed modes %v, want source and binary only", cfg.AllowedModes)
+		}
+		// Unset settings keep their defaults.
+		if cfg.LogLevel != "debug" {
+			t.Errorf("logLevel = %q, want the default 'debug'", cfg.LogLevel)
+		}
+	})
+
+	t.Run("json from environment", func(t *testing.T) {
+		t.Setenv("MCP_DAP_SERVER_CONFIG", jsonPath)
+		cfg, err := testLoadConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.LogLevel != "info" || cfg.GDBPath != "/usr/local/bin/gdb" {
+			t.Errorf("got %+v, want the file's settings", cfg)
+		}
+	})
+
+	t.Run("flags override the file", func(t *testing.T) {
+		cfg, err := testLoadConfig("-config", yamlPath, "-debugger", "delve", "-max-frames", "5", "-full-context=false", "-allowed-modes", "attach,core", "-build-flags", "go=-race")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Debugger != "delve" || cfg.MaxFrames != 5 || cfg.FullContext || cfg.BuildFlags["go"] != "-race" {
+			t.Errorf("got %+v, want the flags' settings", cfg)
+		}
+		if !slices.Equal(cfg.AllowedModes, []string{"attach", "core"}) {
+			t.Errorf("allowed modes %v, want [attach core]", cfg.AllowedModes)
+		}
+		// Settings without a flag keep the file's value.
+		if cfg.DlvPath != "/opt/dlv-1.25/dlv" {
+			t.Errorf("dlvPath = %q, want the file's", cfg.DlvPath)
+		}
+	})
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown setting", []string{"-config", write("typo.yaml", "maxFrame: 5\n")}, "field maxFrame not found"},
+		{"missing file", []string{"-config", filepath.Join(dir, "missing.yaml")}, "unable to read config file"},
+		{"transport", []string{"-transport", "websocket"}, "invalid transport"},
+		{"log level", []string{"-log-level", "trace"}, "invalid log level"},
+		{"debugger", []string{"-debugger", "windbg"}, "unsupported debugger"},
+		{"max frames", []string{"-max-frames", "0"}, "maxFrames must be at least 1"},
+		{"allowed mode", []string{"-allowed-modes", "source,live"}, "invalid allowed mode: live"},
+		{"build flags language", []string{"-config", write("rust.yaml", "buildFlags: {rust: --release}\n")}, "unsupported build flags language: rust"},
+		{"build flags syntax", []string{"-build-flags", "-race"}, "must be 'language=flags'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testLoadConfig(tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("loadConfig(%q) = %v, want an error containing %q", tt.args, err, tt.want)
+			}
+		})
+	}
+}
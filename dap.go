@@ -3,9 +3,14 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/google/go-dap"
 )
@@ -16,49 +21,188 @@ type readWriteCloser struct {
 	io.WriteCloser
 }
 
-// DAPClient is a synchronous Debug Adapter Protocol client.
+// DAPClient is a Debug Adapter Protocol client.
 // It manages a connection to a DAP server and provides methods for
 // sending each DAP request type. Each request method returns the
-// sequence number of the sent request, which callers use to match
-// the corresponding response via request_seq.
+// sequence number of the sent request, which callers pass to response
+// to wait for the matching reply.
+//
+// A background goroutine owns the connection's reader. It routes each
+// response to the caller waiting on its request_seq and fans events out
+// to subscribers, so requests may be issued from several goroutines and
+// answered in any order.
 type DAPClient struct {
-	rwc       io.ReadWriteCloser
-	reader    *bufio.Reader
+	rwc    io.ReadWriteCloser
+	reader *bufio.Reader
+
+	// mu guards the fields below and serializes writes to rwc.
+	mu        sync.Mutex
 	logWriter io.Writer
 	// seq tracks the sequence number for each request sent to the server.
 	seq int
+	// pending holds a channel per request whose response has not yet been
+	// taken by response, keyed by request seq.
+	pending     map[int]chan dap.ResponseMessage
+	subscribers []eventSubscriber
+	nextSubID   int
+
+	done chan struct{} // closed when the read loop exits
+	err  error         // why the read loop exited; valid once done is closed
 }
 
-// newDAPClient creates a new Client over a TCP connection.
+// eventSubscriber is a function registered with Subscribe.
+type eventSubscriber struct {
+	id int
+	f  func(dap.EventMessage)
+}
+
+// newDAPClient creates a new Client over a TCP connection to addr
+// ("host:port"), or over a unix socket if addr is "unix:/path/to/socket".
 // Call Close to close the connection.
 func newDAPClient(addr string) (*DAPClient, error) {
-	conn, err := net.Dial("tcp", addr)
+	network, address := "tcp", addr
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, address = "unix", path
+	}
+	conn, err := net.Dial(network, address)
 	if err != nil {
 		return nil, fmt.Errorf("connecting to DAP server at %s: %w", addr, err)
 	}
 	return newDAPClientFromRWC(conn), nil
 }
 
-// newDAPClientFromRWC creates a new Client with the given ReadWriteCloser.
-// Call Close to close the underlying transport.
+// newDAPClientFromRWC creates a new Client with the given ReadWriteCloser
+// and starts reading from it. Call Close to close the underlying transport.
 func newDAPClientFromRWC(rwc io.ReadWriteCloser) *DAPClient {
-	return &DAPClient{
-		rwc:    rwc,
-		reader: bufio.NewReader(rwc),
-		seq:    1, // match VS Code numbering
+	c := &DAPClient{
+		rwc:     rwc,
+		reader:  bufio.NewReader(rwc),
+		seq:     1, // match VS Code numbering
+		pending: make(map[int]chan dap.ResponseMessage),
+		done:    make(chan struct{}),
 	}
+	go c.readLoop()
+	return c
 }
 
-// Close closes the client connection.
+// Close closes the client connection. Callers waiting for a response
+// return an error once the read loop notices the closed connection.
 func (c *DAPClient) Close() {
 	c.rwc.Close()
 }
 
+// Done returns a channel that is closed when the connection is closed or
+// fails and no further messages will be received.
+func (c *DAPClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection stopped receiving messages. It must only
+// be called after Done is closed.
+func (c *DAPClient) Err() error {
+	return fmt.Errorf("DAP connection closed: %w", c.err)
+}
+
 // SetProtocolLogger sets a writer for logging all DAP messages sent and received.
 func (c *DAPClient) SetProtocolLogger(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.logWriter = w
 }
 
+// Subscribe registers f to be called for every event received from the
+// server, and returns a function that unregisters it. Subscribers are
+// called in registration order on the read loop's goroutine, so f must not
+// block or wait for a response.
+func (c *DAPClient) Subscribe(f func(dap.EventMessage)) (unsubscribe func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.nextSubID
+	c.nextSubID++
+	c.subscribers = append(c.subscribers, eventSubscriber{id: id, f: f})
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.subscribers = slices.DeleteFunc(c.subscribers, func(s eventSubscriber) bool { return s.id == id })
+	}
+}
+
+// readLoop reads messages until the connection fails, delivering
+// responses to their waiting callers and events to subscribers.
+func (c *DAPClient) readLoop() {
+	defer close(c.done)
+	for {
+		msg, err := dap.ReadProtocolMessage(c.reader)
+		if err != nil {
+			// Adapters send events and requests go-dap does not know, such
+			// as debugpy's debugpySockets. The message has been read in full,
+			// so skip it and keep going.
+			var fieldErr *dap.DecodeProtocolMessageFieldError
+			if errors.As(err, &fieldErr) {
+				log.Printf("DAP client: skipping message: %v", err)
+				continue
+			}
+			c.err = err
+			return
+		}
+		c.mu.Lock()
+		c.logMessage("RECV", msg)
+		switch m := msg.(type) {
+		case dap.ResponseMessage:
+			r := m.GetResponse()
+			if ch, ok := c.pending[r.RequestSeq]; ok {
+				// The channel holds one response; a duplicate must not
+				// block the loop.
+				select {
+				case ch <- m:
+				default:
+					log.Printf("DAP client: dropping duplicate %T (request_seq=%d)", m, r.RequestSeq)
+				}
+			} else {
+				log.Printf("DAP client: dropping %T with no pending request (request_seq=%d)", m, r.RequestSeq)
+			}
+			c.mu.Unlock()
+		case dap.EventMessage:
+			subscribers := slices.Clone(c.subscribers)
+			c.mu.Unlock()
+			for _, s := range subscribers {
+				s.f(m)
+			}
+		default:
+			c.mu.Unlock()
+			log.Printf("DAP client: ignoring unexpected %T", m)
+		}
+	}
+}
+
+// response waits for the response to the request with sequence number seq.
+// It fails if the connection closes first. Each response can be taken once.
+func (c *DAPClient) response(seq int) (dap.ResponseMessage, error) {
+	c.mu.Lock()
+	ch, ok := c.pending[seq]
+	c.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no pending request with seq %d", seq)
+	}
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, seq)
+		c.mu.Unlock()
+	}()
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-c.done:
+		// The response may have arrived just before the connection closed.
+		select {
+		case resp := <-ch:
+			return resp, nil
+		default:
+			return nil, c.Err()
+		}
+	}
+}
+
 // InitializeRequest sends an 'initialize' request and returns the server's capabilities.
 func (c *DAPClient) InitializeRequest(adapterID string) (dap.Capabilities, error) {
 	req := c.newRequest("initialize")
@@ -70,43 +214,28 @@ func (c *DAPClient) InitializeRequest(adapterID string) (dap.Capabilities, error
 		ColumnsStartAt1:              true,
 		SupportsVariableType:         true,
 		SupportsVariablePaging:       true,
+		SupportsMemoryReferences:     true,
 		SupportsRunInTerminalRequest: false,
 		Locale:                       "en-us",
 	}
 	if err := c.send(request); err != nil {
 		return dap.Capabilities{}, err
 	}
-	for {
-		msg, err := c.ReadMessage()
-		if err != nil {
-			return dap.Capabilities{}, err
-		}
-		switch resp := msg.(type) {
-		case *dap.InitializeResponse:
-			if !resp.Success {
-				return dap.Capabilities{}, fmt.Errorf("initialize failed: %s", resp.Message)
-			}
-			return resp.Body, nil
-		case dap.EventMessage:
-			// Skip events (e.g. OutputEvent) during initialization and keep reading
-			continue
-		default:
-			return dap.Capabilities{}, fmt.Errorf("expected InitializeResponse, got %T", msg)
-		}
-	}
-}
-
-func (c *DAPClient) ReadMessage() (dap.Message, error) {
-	msg, err := dap.ReadProtocolMessage(c.reader)
+	msg, err := c.response(req.Seq)
 	if err != nil {
-		return nil, err
+		return dap.Capabilities{}, err
 	}
-	if c.logWriter != nil {
-		if data, merr := json.Marshal(msg); merr == nil {
-			fmt.Fprintf(c.logWriter, "RECV: <<<%s>>>\n", data)
+	resp, ok := msg.(*dap.InitializeResponse)
+	if !ok {
+		if r := msg.GetResponse(); !r.Success {
+			return dap.Capabilities{}, fmt.Errorf("initialize failed: %s", r.Message)
 		}
+		return dap.Capabilities{}, fmt.Errorf("expected InitializeResponse, got %T", msg)
 	}
-	return msg, nil
+	if !resp.Success {
+		return dap.Capabilities{}, fmt.Errorf("initialize failed: %s", resp.Message)
+	}
+	return resp.Body, nil
 }
 
 // LaunchRequest sends a 'launch' request with the specified args.
@@ -140,24 +269,41 @@ func (c *DAPClient) CoreRequest(program, coreFilePath string) (int, error) {
 }
 
 // newRequest creates a new DAP request with the given command and an
-// auto-incremented sequence number. The caller can read the assigned
-// sequence number from the returned request's Seq field.
+// auto-incremented sequence number, and registers it as awaiting a
+// response. The caller can read the assigned sequence number from the
+// returned request's Seq field.
 func (c *DAPClient) newRequest(command string) *dap.Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	request := &dap.Request{}
 	request.Type = "request"
 	request.Command = command
 	request.Seq = c.seq
 	c.seq++
+	c.pending[request.Seq] = make(chan dap.ResponseMessage, 1)
 	return request
 }
 
 func (c *DAPClient) send(request dap.Message) error {
-	if c.logWriter != nil {
-		if data, err := json.Marshal(request); err == nil {
-			fmt.Fprintf(c.logWriter, "SENT: <<<%s>>>\n", data)
-		}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logMessage("SENT", request)
+	if err := dap.WriteProtocolMessage(c.rwc, request); err != nil {
+		// No response will come, so forget the request.
+		delete(c.pending, request.GetSeq())
+		return err
+	}
+	return nil
+}
+
+// logMessage writes msg to the protocol log, if any. Callers must hold c.mu.
+func (c *DAPClient) logMessage(direction string, msg dap.Message) {
+	if c.logWriter == nil {
+		return
+	}
+	if data, err := json.Marshal(msg); err == nil {
+		fmt.Fprintf(c.logWriter, "%s: <<<%s>>>\n", direction, data)
 	}
-	return dap.WriteProtocolMessage(c.rwc, request)
 }
 
 func toRawMessage(in any) json.RawMessage {
@@ -166,7 +312,8 @@ func toRawMessage(in any) json.RawMessage {
 }
 
 // SetBreakpointsRequest sends a 'setBreakpoints' request.
-func (c *DAPClient) SetBreakpointsRequest(file string, lines []int) (int, error) {
+// The breakpoints replace every breakpoint previously set in file.
+func (c *DAPClient) SetBreakpointsRequest(file string, breakpoints []dap.SourceBreakpoint) (int, error) {
 	req := c.newRequest("setBreakpoints")
 	request := &dap.SetBreakpointsRequest{Request: *req}
 	request.Arguments = dap.SetBreakpointsArguments{
@@ -174,23 +321,26 @@ func (c *DAPClient) SetBreakpointsRequest(file string, lines []int) (int, error)
 			Name: file,
 			Path: file,
 		},
-		Breakpoints: make([]dap.SourceBreakpoint, len(lines)),
+		Breakpoints: breakpoints,
 	}
-	for i, l := range lines {
-		request.Arguments.Breakpoints[i].Line = l
+	if request.Arguments.Breakpoints == nil {
+		// Send an empty list rather than null to clear all breakpoints.
+		request.Arguments.Breakpoints = []dap.SourceBreakpoint{}
 	}
 	return req.Seq, c.send(request)
 }
 
 // SetFunctionBreakpointsRequest sends a 'setFunctionBreakpoints' request.
-func (c *DAPClient) SetFunctionBreakpointsRequest(functions []string) (int, error) {
+// The breakpoints replace every function breakpoint previously set.
+func (c *DAPClient) SetFunctionBreakpointsRequest(breakpoints []dap.FunctionBreakpoint) (int, error) {
 	req := c.newRequest("setFunctionBreakpoints")
 	request := &dap.SetFunctionBreakpointsRequest{Request: *req}
 	request.Arguments = dap.SetFunctionBreakpointsArguments{
-		Breakpoints: make([]dap.FunctionBreakpoint, len(functions)),
+		Breakpoints: breakpoints,
 	}
-	for i, f := range functions {
-		request.Arguments.Breakpoints[i].Name = f
+	if request.Arguments.Breakpoints == nil {
+		// Send an empty list rather than null to clear all breakpoints.
+		request.Arguments.Breakpoints = []dap.FunctionBreakpoint{}
 	}
 	return req.Seq, c.send(request)
 }
@@ -210,27 +360,33 @@ func (c *DAPClient) ContinueRequest(threadID int) (int, error) {
 	return req.Seq, c.send(request)
 }
 
-// NextRequest sends a 'next' request.
-func (c *DAPClient) NextRequest(threadID int) (int, error) {
+// NextRequest sends a 'next' request. An empty granularity steps by
+// line; "instruction" steps by machine instruction.
+func (c *DAPClient) NextRequest(threadID int, granularity dap.SteppingGranularity) (int, error) {
 	req := c.newRequest("next")
 	request := &dap.NextRequest{Request: *req}
 	request.Arguments.ThreadId = threadID
+	request.Arguments.Granularity = granularity
 	return req.Seq, c.send(request)
 }
 
-// StepInRequest sends a 'stepIn' request.
-func (c *DAPClient) StepInRequest(threadID int) (int, error) {
+// StepInRequest sends a 'stepIn' request. An empty granularity steps by
+// line; "instruction" steps by machine instruction.
+func (c *DAPClient) StepInRequest(threadID int, granularity dap.SteppingGranularity) (int, error) {
 	req := c.newRequest("stepIn")
 	request := &dap.StepInRequest{Request: *req}
 	request.Arguments.ThreadId = threadID
+	request.Arguments.Granularity = granularity
 	return req.Seq, c.send(request)
 }
 
-// StepOutRequest sends a 'stepOut' request.
-func (c *DAPClient) StepOutRequest(threadID int) (int, error) {
+// StepOutRequest sends a 'stepOut' request. An empty granularity steps by
+// line; "instruction" steps by machine instruction.
+func (c *DAPClient) StepOutRequest(threadID int, granularity dap.SteppingGranularity) (int, error) {
 	req := c.newRequest("stepOut")
 	request := &dap.StepOutRequest{Request: *req}
 	request.Arguments.ThreadId = threadID
+	request.Arguments.Granularity = granularity
 	return req.Seq, c.send(request)
 }
 
@@ -275,6 +431,19 @@ func (c *DAPClient) VariablesRequest(variablesReference int) (int, error) {
 	return req.Seq, c.send(request)
 }
 
+// PagedVariablesRequest sends a 'variables' request for a range of children.
+// filter is "indexed", "named", or empty for all children; start and count
+// select the range (count 0 means all remaining children).
+func (c *DAPClient) PagedVariablesRequest(variablesReference int, filter string, start, count int) (int, error) {
+	req := c.newRequest("variables")
+	request := &dap.VariablesRequest{Request: *req}
+	request.Arguments.VariablesReference = variablesReference
+	request.Arguments.Filter = filter
+	request.Arguments.Start = start
+	request.Arguments.Count = count
+	return req.Seq, c.send(request)
+}
+
 // EvaluateRequest sends an 'evaluate' request.
 // We build the arguments as raw JSON instead of using dap.EvaluateArguments
 // because go-dap uses omitempty on FrameId, which drops frameId=0 from the
@@ -293,12 +462,7 @@ func (c *DAPClient) EvaluateRequest(expression string, frameID int, context stri
 		dap.Request
 		Arguments map[string]any `json:"arguments"`
 	}{Request: *req, Arguments: args}
-	if c.logWriter != nil {
-		if data, err := json.Marshal(&msg); err == nil {
-			fmt.Fprintf(c.logWriter, "SENT: <<<%s>>>\n", data)
-		}
-	}
-	return req.Seq, dap.WriteProtocolMessage(c.rwc, &msg)
+	return req.Seq, c.send(&msg)
 }
 
 // DisconnectRequest sends a 'disconnect' request.
@@ -346,11 +510,21 @@ func (c *DAPClient) TerminateRequest() (int, error) {
 	return req.Seq, c.send(request)
 }
 
-// StepBackRequest sends a 'stepBack' request.
-func (c *DAPClient) StepBackRequest(threadID int) (int, error) {
+// StepBackRequest sends a 'stepBack' request with the given granularity, as
+// NextRequest does.
+func (c *DAPClient) StepBackRequest(threadID int, granularity dap.SteppingGranularity) (int, error) {
 	req := c.newRequest("stepBack")
 	request := &dap.StepBackRequest{Request: *req}
 	request.Arguments.ThreadId = threadID
+	request.Arguments.Granularity = granularity
+	return req.Seq, c.send(request)
+}
+
+// ReverseContinueRequest sends a 'reverseContinue' request.
+func (c *DAPClient) ReverseContinueRequest(threadID int) (int, error) {
+	req := c.newRequest("reverseContinue")
+	request := &dap.ReverseContinueRequest{Request: *req}
+	request.Arguments.ThreadId = threadID
 	return req.Seq, c.send(request)
 }
 
@@ -399,20 +573,51 @@ func (c *DAPClient) DisassembleRequest(memoryReference string, instructionOffset
 	return req.Seq, c.send(request)
 }
 
+// ReadMemoryRequest sends a 'readMemory' request for count bytes at offset
+// bytes from memoryReference.
+func (c *DAPClient) ReadMemoryRequest(memoryReference string, offset, count int) (int, error) {
+	req := c.newRequest("readMemory")
+	request := &dap.ReadMemoryRequest{Request: *req}
+	request.Arguments.MemoryReference = memoryReference
+	request.Arguments.Offset = offset
+	request.Arguments.Count = count
+	return req.Seq, c.send(request)
+}
+
+// WriteMemoryRequest sends a 'writeMemory' request for the base64-encoded
+// data at offset bytes from memoryReference.
+func (c *DAPClient) WriteMemoryRequest(memoryReference string, offset int, data string) (int, error) {
+	req := c.newRequest("writeMemory")
+	request := &dap.WriteMemoryRequest{Request: *req}
+	request.Arguments.MemoryReference = memoryReference
+	request.Arguments.Offset = offset
+	request.Arguments.Data = data
+	return req.Seq, c.send(request)
+}
+
 // SetExceptionBreakpointsRequest sends a 'setExceptionBreakpoints' request.
-func (c *DAPClient) SetExceptionBreakpointsRequest(filters []string) (int, error) {
+// filterOptions is only valid when the adapter supports exception filter
+// options.
+func (c *DAPClient) SetExceptionBreakpointsRequest(filters []string, filterOptions []dap.ExceptionFilterOptions) (int, error) {
 	req := c.newRequest("setExceptionBreakpoints")
 	request := &dap.SetExceptionBreakpointsRequest{Request: *req}
 	request.Arguments.Filters = filters
+	if request.Arguments.Filters == nil {
+		// filters is required; send an empty list to disable all filters.
+		request.Arguments.Filters = []string{}
+	}
+	request.Arguments.FilterOptions = filterOptions
 	return req.Seq, c.send(request)
 }
 
-// DataBreakpointInfoRequest sends a 'dataBreakpointInfo' request.
-func (c *DAPClient) DataBreakpointInfoRequest(variablesRef int, name string) (int, error) {
+// DataBreakpointInfoRequest sends a 'dataBreakpointInfo' request. With a zero
+// variablesRef, name is an expression evaluated in frameID.
+func (c *DAPClient) DataBreakpointInfoRequest(variablesRef int, name string, frameID int) (int, error) {
 	req := c.newRequest("dataBreakpointInfo")
 	request := &dap.DataBreakpointInfoRequest{Request: *req}
 	request.Arguments.VariablesReference = variablesRef
 	request.Arguments.Name = name
+	request.Arguments.FrameId = frameID
 	return req.Seq, c.send(request)
 }
 
@@ -421,6 +626,22 @@ func (c *DAPClient) SetDataBreakpointsRequest(breakpoints []dap.DataBreakpoint)
 	req := c.newRequest("setDataBreakpoints")
 	request := &dap.SetDataBreakpointsRequest{Request: *req}
 	request.Arguments.Breakpoints = breakpoints
+	if request.Arguments.Breakpoints == nil {
+		// Send an empty list rather than null to clear all breakpoints.
+		request.Arguments.Breakpoints = []dap.DataBreakpoint{}
+	}
+	return req.Seq, c.send(request)
+}
+
+// SetInstructionBreakpointsRequest sends a 'setInstructionBreakpoints' request.
+func (c *DAPClient) SetInstructionBreakpointsRequest(breakpoints []dap.InstructionBreakpoint) (int, error) {
+	req := c.newRequest("setInstructionBreakpoints")
+	request := &dap.SetInstructionBreakpointsRequest{Request: *req}
+	request.Arguments.Breakpoints = breakpoints
+	if request.Arguments.Breakpoints == nil {
+		// Send an empty list rather than null to clear all breakpoints.
+		request.Arguments.Breakpoints = []dap.InstructionBreakpoint{}
+	}
 	return req.Seq, c.send(request)
 }
 
@@ -3,7 +3,10 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
+	"net"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-dap"
@@ -53,8 +56,8 @@ func TestNewDAPClientFromRWC(t *testing.T) {
 		_ = dap.WriteProtocolMessage(serverWriter, resp)
 	}()
 
-	// Read the response through the client
-	respMsg, err := client.ReadMessage()
+	// Wait for the response through the client
+	respMsg, err := client.response(1)
 	if err != nil {
 		t.Fatalf("failed to read response: %v", err)
 	}
@@ -73,3 +76,176 @@ func TestNewDAPClientFromRWC(t *testing.T) {
 		t.Error("expected error writing to closed connection")
 	}
 }
+
+// newPipeClient returns a client connected to an in-memory server. Requests
+// the client sends are delivered on the returned channel; responses and
+// events written to the returned writer are received by the client.
+func newPipeClient(t *testing.T) (*DAPClient, <-chan dap.Message, io.WriteCloser) {
+	t.Helper()
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	client := newDAPClientFromRWC(&readWriteCloser{
+		Reader:      clientReader,
+		WriteCloser: clientWriter,
+	})
+	requests := make(chan dap.Message, 16)
+	go func() {
+		r := bufio.NewReader(serverReader)
+		for {
+			msg, err := dap.ReadProtocolMessage(r)
+			if err != nil {
+				return
+			}
+			requests <- msg
+		}
+	}()
+	t.Cleanup(func() {
+		client.Close()
+		serverWriter.Close()
+	})
+	return client, requests, serverWriter
+}
+
+func newThreadsResponse(requestSeq, threadID int) *dap.ThreadsResponse {
+	resp := &dap.ThreadsResponse{}
+	resp.Type = "response"
+	resp.Command = "threads"
+	resp.RequestSeq = requestSeq
+	resp.Success = true
+	resp.Body.Threads = []dap.Thread{{Id: threadID}}
+	return resp
+}
+
+func TestDAPClientRoutesResponsesBySeq(t *testing.T) {
+	client, requests, serverWriter := newPipeClient(t)
+
+	var seqs []int
+	for range 2 {
+		seq, err := client.ThreadsRequest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-requests
+		seqs = append(seqs, seq)
+	}
+
+	// Answer the requests in reverse order, with an event in between.
+	go func() {
+		for i := len(seqs) - 1; i >= 0; i-- {
+			dap.WriteProtocolMessage(serverWriter, newThreadsResponse(seqs[i], i+1))
+			ev := &dap.OutputEvent{}
+			ev.Type = "event"
+			ev.Event.Event = "output"
+			dap.WriteProtocolMessage(serverWriter, ev)
+		}
+	}()
+
+	for i, seq := range seqs {
+		resp, err := readTypedResponse[*dap.ThreadsResponse](client, seq)
+		if err != nil {
+			t.Fatalf("response to request %d: %v", seq, err)
+		}
+		if got := resp.Body.Threads[0].Id; got != i+1 {
+			t.Errorf("response to request %d has thread %d, want %d", seq, got, i+1)
+		}
+	}
+}
+
+func TestDAPClientSubscribe(t *testing.T) {
+	client, requests, serverWriter := newPipeClient(t)
+
+	events := make(chan dap.EventMessage, 2)
+	unsubscribe := client.Subscribe(func(ev dap.EventMessage) { events <- ev })
+
+	stopped := &dap.StoppedEvent{}
+	stopped.Type = "event"
+	stopped.Event.Event = "stopped"
+	stopped.Body.Reason = "pause"
+	go dap.WriteProtocolMessage(serverWriter, stopped)
+	ev := <-events
+	if s, ok := ev.(*dap.StoppedEvent); !ok || s.Body.Reason != "pause" {
+		t.Fatalf("got event %#v, want stopped event with reason pause", ev)
+	}
+
+	// After unsubscribing, send the event again followed by a response, so
+	// the event has been dispatched once the response arrives.
+	unsubscribe()
+	seq, err := client.ThreadsRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-requests
+	go func() {
+		dap.WriteProtocolMessage(serverWriter, stopped)
+		dap.WriteProtocolMessage(serverWriter, newThreadsResponse(seq, 1))
+	}()
+	if _, err := client.response(seq); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("got event %#v after unsubscribing", ev)
+	default:
+	}
+}
+
+func TestDAPClientSkipsUnknownEvents(t *testing.T) {
+	client, requests, serverWriter := newPipeClient(t)
+
+	seq, err := client.ThreadsRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-requests
+
+	// go-dap cannot decode debugpy's debugpySockets event; the response
+	// after it must still arrive.
+	go func() {
+		body := `{"seq":1,"type":"event","event":"debugpySockets","body":{"sockets":[]}}`
+		fmt.Fprintf(serverWriter, "Content-Length: %d\r\n\r\n%s", len(body), body)
+		dap.WriteProtocolMessage(serverWriter, newThreadsResponse(seq, 1))
+	}()
+	if _, err := readTypedResponse[*dap.ThreadsResponse](client, seq); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDAPClientResponseAfterClose(t *testing.T) {
+	client, requests, serverWriter := newPipeClient(t)
+
+	seq, err := client.ThreadsRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-requests
+	serverWriter.Close()
+
+	if _, err := client.response(seq); err == nil {
+		t.Fatal("expected error waiting for a response on a closed connection")
+	}
+	<-client.Done()
+}
+
+func TestNewDAPClientUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "dap.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets not available: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	client, err := newDAPClient("unix:" + sock)
+	if err != nil {
+		t.Fatalf("newDAPClient: %v", err)
+	}
+	defer client.Close()
+	conn := <-accepted
+	conn.Close()
+}
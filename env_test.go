@@ -0,0 +1,68 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	env, err := parseEnvFile(strings.NewReader(`
+# settings for the integration environment
+CONFIG_PATH=/etc/app.yaml
+export LOG_LEVEL = debug
+GREETING="hello\nworld"
+PATTERN='a\nb'
+URL=http://localhost:8080/#top  # the fragment stays
+EMPTY=
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"CONFIG_PATH": "/etc/app.yaml",
+		"LOG_LEVEL":   "debug",
+		"GREETING":    "hello\nworld",
+		"PATTERN":     `a\nb`,
+		"URL":         "http://localhost:8080/#top",
+		"EMPTY":       "",
+	}
+	if !maps.Equal(env, want) {
+		t.Errorf("got %q, want %q", env, want)
+	}
+
+	for _, bad := range []string{"NOVALUE\n", "=value\n", "TWO WORDS=1\n", `BAD="unterminated\"` + "\n"} {
+		if _, err := parseEnvFile(strings.NewReader(bad)); err == nil {
+			t.Errorf("parseEnvFile(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestLaunchOptions(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "app.env")
+	if err := os.WriteFile(envFile, []byte("CONFIG_PATH=/etc/app.yaml\nMODE=file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params := DebugParams{EnvFile: envFile, Env: map[string]string{"MODE": "call"}, Cwd: "/srv/app", Args: []string{"-v"}}
+	opts, err := params.launchOptions("-tags=integration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"CONFIG_PATH": "/etc/app.yaml", "MODE": "call"}; !maps.Equal(opts.Env, want) {
+		t.Errorf("env = %v, want %v, with env overriding envFile", opts.Env, want)
+	}
+	if opts.Cwd != "/srv/app" || len(opts.Args) != 1 || opts.BuildFlags != "-tags=integration" {
+		t.Errorf("got %+v, want the parameters with the default build flags", opts)
+	}
+
+	params = DebugParams{Env: map[string]string{"MODE": "call"}, BuildFlags: "-race"}
+	if opts, err = params.launchOptions("-tags=integration"); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Env["MODE"] != "call" || opts.BuildFlags != "-race" {
+		t.Errorf("got %+v, want env without an envFile and the call's build flags", opts)
+	}
+}
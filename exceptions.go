@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ExceptionBreakpointsParams defines the parameters for configuring
+// exception breakpoints.
+type ExceptionBreakpointsParams struct {
+	SessionParam
+	Enable    []string `json:"enable,omitempty" mcp:"exception filters to enable, e.g. ['panic']"`
+	Disable   []string `json:"disable,omitempty" mcp:"exception filters to disable"`
+	Condition string   `json:"condition,omitempty" mcp:"only stop on exceptions for which this expression is true; applies to the filters in enable"`
+}
+
+// exceptionBreakpoints enables or disables exception filters and lists them.
+func (ds *debuggerSession) exceptionBreakpoints(ctx context.Context, _ *mcp.CallToolRequest, params ExceptionBreakpointsParams) (*mcp.CallToolResult, *ExceptionFiltersResult, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.client == nil {
+		return nil, nil, fmt.Errorf("debugger not started")
+	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
+
+	if len(params.Enable) == 0 && len(params.Disable) == 0 {
+		return ds.exceptionFiltersResult()
+	}
+	if err := validateExceptionFilters(ds.capabilities, params.Enable, params.Condition); err != nil {
+		return nil, nil, err
+	}
+	if err := validateExceptionFilters(ds.capabilities, params.Disable, ""); err != nil {
+		return nil, nil, err
+	}
+
+	prev := ds.breakpoints.exceptionFilters()
+	for _, filter := range params.Disable {
+		ds.breakpoints.removeException(filter)
+	}
+	for _, filter := range params.Enable {
+		ds.breakpoints.setException(dap.ExceptionFilterOptions{FilterId: filter, Condition: params.Condition})
+	}
+	ids, bps, err := ds.syncExceptionBreakpoints()
+	if err != nil {
+		ds.breakpoints.exceptions = prev
+		return nil, nil, err
+	}
+	var unverified []string
+	for i, bp := range bps {
+		if i < len(ids) && !bp.Verified && slices.Contains(params.Enable, ids[i]) {
+			ds.breakpoints.removeException(ids[i])
+			unverified = append(unverified, fmt.Sprintf("%s: %s", ids[i], bp.Message))
+		}
+	}
+	if len(unverified) > 0 {
+		if _, _, err := ds.syncExceptionBreakpoints(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("exception breakpoints not verified: %s", strings.Join(unverified, "; "))
+	}
+
+	return ds.exceptionFiltersResult()
+}
+
+// validateExceptionFilters checks that the adapter offers each filter and,
+// if a condition is given, that it accepts one for each filter.
+func validateExceptionFilters(caps dap.Capabilities, filters []string, condition string) error {
+	if condition != "" && !caps.SupportsExceptionFilterOptions {
+		return fmt.Errorf("debug adapter does not support exception filter conditions")
+	}
+	for _, id := range filters {
+		i := slices.IndexFunc(caps.ExceptionBreakpointFilters, func(f dap.ExceptionBreakpointsFilter) bool { return f.Filter == id })
+		if i < 0 {
+			return fmt.Errorf("unknown exception filter: %s (available: %s)", id, exceptionFilterIDs(caps.ExceptionBreakpointFilters))
+		}
+		if condition != "" && !caps.ExceptionBreakpointFilters[i].SupportsCondition {
+			return fmt.Errorf("exception filter %s does not support a condition", id)
+		}
+	}
+	return nil
+}
+
+// exceptionFilterIDs lists filter IDs for messages and tool descriptions.
+func exceptionFilterIDs(filters []dap.ExceptionBreakpointsFilter) string {
+	ids := make([]string, len(filters))
+	for i, f := range filters {
+		ids[i] = "'" + f.Filter + "'"
+	}
+	return strings.Join(ids, ", ")
+}
+
+// exceptionFiltersResult lists the adapter's exception filters and which
+// of them are enabled.
+func (ds *debuggerSession) exceptionFiltersResult() (*mcp.CallToolResult, *ExceptionFiltersResult, error) {
+	out := &ExceptionFiltersResult{Filters: make([]ExceptionFilter, len(ds.capabilities.ExceptionBreakpointFilters))}
+	enabled := ds.breakpoints.exceptionFilters()
+	for i, f := range ds.capabilities.ExceptionBreakpointFilters {
+		out.Filters[i] = ExceptionFilter{Filter: f.Filter, Label: f.Label, Description: f.Description}
+		j := slices.IndexFunc(enabled, func(opt dap.ExceptionFilterOptions) bool { return opt.FilterId == f.Filter })
+		if j >= 0 {
+			out.Filters[i].Enabled = true
+			out.Filters[i].Condition = enabled[j].Condition
+		}
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: formatExceptionFilters(out.Filters)}},
+	}, out, nil
+}
+
+// formatExceptionFilters lists exception filters, marking the enabled ones.
+func formatExceptionFilters(filters []ExceptionFilter) string {
+	var result strings.Builder
+	result.WriteString("Exception filters:\n")
+	for _, f := range filters {
+		mark := " "
+		if f.Enabled {
+			mark = "x"
+		}
+		fmt.Fprintf(&result, "  [%s] %s", mark, f.Filter)
+		if f.Label != "" && f.Label != f.Filter {
+			fmt.Fprintf(&result, " — %s", f.Label)
+		}
+		if f.Description != "" {
+			fmt.Fprintf(&result, ": %s", f.Description)
+		}
+		if f.Condition != "" {
+			fmt.Fprintf(&result, " (condition: %s)", f.Condition)
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// defaultExceptionFilters returns the filters the adapter enables by default.
+func defaultExceptionFilters(caps dap.Capabilities) []string {
+	var ids []string
+	for _, f := range caps.ExceptionBreakpointFilters {
+		if f.Default {
+			ids = append(ids, f.Filter)
+		}
+	}
+	return ids
+}
+
+// isExceptionStop reports whether a stopped event reason means the program
+// stopped on an exception or panic.
+func isExceptionStop(reason string) bool {
+	return reason == "exception" || reason == "panic"
+}
+
+// exceptionInfo fetches the exception that stopped threadID. Errors are
+// recorded in the result rather than returned, since the stop itself is
+// still worth reporting.
+func (ds *debuggerSession) exceptionInfo(threadID int) *ExceptionResult {
+	seq, err := ds.client.ExceptionInfoRequest(threadID)
+	if err != nil {
+		return &ExceptionResult{Error: fmt.Sprintf("unable to get exception info: %v", err)}
+	}
+	resp, err := readTypedResponse[*dap.ExceptionInfoResponse](ds.client, seq)
+	if err != nil {
+		return &ExceptionResult{Error: fmt.Sprintf("unable to get exception info: %v", err)}
+	}
+	return newExceptionResult(resp.Body)
+}
+
+// newExceptionResult converts an exceptionInfo response body, flattening
+// the chain of inner exceptions.
+func newExceptionResult(info dap.ExceptionInfoResponseBody) *ExceptionResult {
+	exc := &ExceptionResult{
+		ID:          info.ExceptionId,
+		Description: info.Description,
+		BreakMode:   string(info.BreakMode),
+	}
+	if info.Details != nil {
+		exc.Details = appendExceptionDetails(exc.Details, *info.Details, 0)
+	}
+	return exc
+}
+
+// appendExceptionDetails appends d and then its inner exceptions to details.
+func appendExceptionDetails(details []ExceptionDetail, d dap.ExceptionDetails, depth int) []ExceptionDetail {
+	typeName := d.FullTypeName
+	if typeName == "" {
+		typeName = d.TypeName
+	}
+	details = append(details, ExceptionDetail{
+		Depth:        depth,
+		TypeName:     typeName,
+		Message:      d.Message,
+		EvaluateName: d.EvaluateName,
+		StackTrace:   d.StackTrace,
+	})
+	for _, inner := range d.InnerException {
+		details = appendExceptionDetails(details, inner, depth+1)
+	}
+	return details
+}
+
+// formatException renders an exception as a context section, indenting
+// each level of inner exceptions.
+func formatException(exc *ExceptionResult) string {
+	var result strings.Builder
+	result.WriteString("## Exception\n")
+	if exc.Error != "" {
+		fmt.Fprintf(&result, "(%s)\n", exc.Error)
+		return result.String()
+	}
+	fmt.Fprintf(&result, "ID: %s\n", exc.ID)
+	if exc.Description != "" {
+		fmt.Fprintf(&result, "Description: %s\n", exc.Description)
+	}
+	if exc.BreakMode != "" {
+		fmt.Fprintf(&result, "Break mode: %s\n", exc.BreakMode)
+	}
+	for _, d := range exc.Details {
+		indent := strings.Repeat("  ", d.Depth)
+		if d.Depth > 0 {
+			fmt.Fprintf(&result, "%sInner exception:\n", indent[2:])
+		}
+		if d.TypeName != "" {
+			fmt.Fprintf(&result, "%sType: %s\n", indent, d.TypeName)
+		}
+		if d.Message != "" {
+			fmt.Fprintf(&result, "%sMessage: %s\n", indent, d.Message)
+		}
+		if d.EvaluateName != "" {
+			fmt.Fprintf(&result, "%sEvaluate: %s\n", indent, d.EvaluateName)
+		}
+		if d.StackTrace != "" {
+			fmt.Fprintf(&result, "%sStack trace:\n", indent)
+			for _, line := range strings.Split(strings.TrimRight(d.StackTrace, "\n"), "\n") {
+				fmt.Fprintf(&result, "%s  %s\n", indent, line)
+			}
+		}
+	}
+	return result.String()
+}
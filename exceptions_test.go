@@ -0,0 +1,70 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-dap"
+)
+
+func TestValidateExceptionFilters(t *testing.T) {
+	caps := dap.Capabilities{
+		ExceptionBreakpointFilters: []dap.ExceptionBreakpointsFilter{
+			{Filter: "raised", SupportsCondition: true},
+			{Filter: "uncaught", Default: true},
+		},
+	}
+
+	if err := validateExceptionFilters(caps, []string{"raised", "uncaught"}, ""); err != nil {
+		t.Errorf("unexpected error for known filters: %v", err)
+	}
+	if err := validateExceptionFilters(caps, []string{"panic"}, ""); err == nil || !strings.Contains(err.Error(), "'raised', 'uncaught'") {
+		t.Errorf("expected unknown filter error listing the available filters, got: %v", err)
+	}
+	if err := validateExceptionFilters(caps, []string{"raised"}, "x"); err == nil {
+		t.Error("expected error for a condition without SupportsExceptionFilterOptions")
+	}
+
+	caps.SupportsExceptionFilterOptions = true
+	if err := validateExceptionFilters(caps, []string{"raised"}, "x"); err != nil {
+		t.Errorf("unexpected error for a condition on a conditional filter: %v", err)
+	}
+	if err := validateExceptionFilters(caps, []string{"uncaught"}, "x"); err == nil {
+		t.Error("expected error for a condition on a filter that does not support one")
+	}
+
+	if got := defaultExceptionFilters(caps); !slices.Equal(got, []string{"uncaught"}) {
+		t.Errorf("defaultExceptionFilters = %v, want [uncaught]", got)
+	}
+}
+
+func TestFormatException(t *testing.T) {
+	got := formatException(newExceptionResult(dap.ExceptionInfoResponseBody{
+		ExceptionId: "panic",
+		Description: "runtime error: index out of range [5] with length 3",
+		BreakMode:   "unhandled",
+		Details: &dap.ExceptionDetails{
+			TypeName:   "runtime.boundsError",
+			StackTrace: "main.main()\n\t/app/main.go:9\n",
+			InnerException: []dap.ExceptionDetails{
+				{TypeName: "inner.Error", Message: "cause"},
+			},
+		},
+	}))
+	want := `## Exception
+ID: panic
+Description: runtime error: index out of range [5] with length 3
+Break mode: unhandled
+Type: runtime.boundsError
+Stack trace:
+  main.main()
+  	/app/main.go:9
+Inner exception:
+  Type: inner.Error
+  Message: cause
+`
+	if got != want {
+		t.Errorf("formatException =\n%s\nwant:\n%s", got, want)
+	}
+}
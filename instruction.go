@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// stepInstructionCount is the number of instructions a stop after stepping
+// by instruction shows, starting with the one about to execute.
+const stepInstructionCount = 3
+
+// formatInstructions renders instructions with '=>' marking the first, the
+// next to execute.
+func formatInstructions(insts []Instruction) string {
+	var result strings.Builder
+	result.WriteString("Next instructions:\n")
+	for i, inst := range insts {
+		marker := "  "
+		if i == 0 {
+			marker = "=>"
+		}
+		fmt.Fprintf(&result, "%s %s  %s", marker, inst.Address, inst.Instruction)
+		if inst.File != "" {
+			fmt.Fprintf(&result, "  ; %s:%d", inst.File, inst.Line)
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// InstructionBreakpointParams defines the parameters for setting an
+// instruction breakpoint.
+type InstructionBreakpointParams struct {
+	SessionParam
+	Address      string  `json:"address,omitempty" mcp:"address of the instruction, as shown by 'disassemble' or a frame's instructionPointer; omit to list instruction breakpoints"`
+	Offset       FlexInt `json:"offset,omitempty" mcp:"byte offset from address (default: 0)"`
+	Condition    string  `json:"condition,omitempty" mcp:"expression that must be true for the breakpoint to stop"`
+	HitCondition string  `json:"hitCondition,omitempty" mcp:"stop only once the hit count matches, e.g. '>= 10'"`
+	Remove       bool    `json:"remove,omitempty" mcp:"remove the breakpoint at address instead of setting one"`
+}
+
+// instructionBreakpoint sets, removes or lists instruction breakpoints.
+func (ds *debuggerSession) instructionBreakpoint(ctx context.Context, _ *mcp.CallToolRequest, params InstructionBreakpointParams) (*mcp.CallToolResult, *InstructionBreakpointResult, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.client == nil {
+		return nil, nil, fmt.Errorf("debugger not started")
+	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
+	if !ds.capabilities.SupportsInstructionBreakpoints {
+		return nil, nil, fmt.Errorf("debug adapter does not support instruction breakpoints")
+	}
+
+	if params.Address == "" {
+		return ds.instructionBreakpointsResult("")
+	}
+	offset := params.Offset.Int()
+
+	if params.Remove {
+		prev := ds.breakpoints.instructionBreakpoints()
+		ds.breakpoints.removeInstruction(params.Address, offset)
+		if len(ds.breakpoints.instructions) == len(prev) {
+			return nil, nil, fmt.Errorf("no instruction breakpoint at %s", instructionLocation(params.Address, offset))
+		}
+		if _, err := ds.syncInstructionBreakpoints(); err != nil {
+			return nil, nil, err
+		}
+		return ds.instructionBreakpointsResult(fmt.Sprintf("Removed instruction breakpoint at %s", instructionLocation(params.Address, offset)))
+	}
+
+	if err := (BreakpointSpec{Condition: params.Condition, HitCondition: params.HitCondition}).validate(ds.capabilities); err != nil {
+		return nil, nil, err
+	}
+	prev := ds.breakpoints.instructionBreakpoints()
+	bp := dap.InstructionBreakpoint{
+		InstructionReference: params.Address,
+		Offset:               offset,
+		Condition:            params.Condition,
+		HitCondition:         params.HitCondition,
+	}
+	ds.breakpoints.setInstruction(bp)
+	bps, err := ds.syncInstructionBreakpoints()
+	if err != nil {
+		ds.breakpoints.instructions = prev
+		return nil, nil, err
+	}
+	for i, ibp := range ds.breakpoints.instructionBreakpoints() {
+		if ibp.InstructionReference != bp.InstructionReference || ibp.Offset != offset || i >= len(bps) || bps[i].Verified {
+			continue
+		}
+		ds.breakpoints.removeInstruction(bp.InstructionReference, offset)
+		if _, err := ds.syncInstructionBreakpoints(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("instruction breakpoint not verified: %s", bps[i].Message)
+	}
+	return ds.instructionBreakpointsResult(fmt.Sprintf("Instruction breakpoint set at %s", instructionLocation(params.Address, offset)))
+}
+
+// instructionBreakpointsResult lists the registered instruction breakpoints
+// after an optional message. Callers must hold ds.mu.
+func (ds *debuggerSession) instructionBreakpointsResult(message string) (*mcp.CallToolResult, *InstructionBreakpointResult, error) {
+	out := &InstructionBreakpointResult{Breakpoints: newInstructionBreakpoints(ds.breakpoints.instructionBreakpoints())}
+	text := formatInstructionBreakpoints(out.Breakpoints)
+	if message != "" {
+		text = message + "\n\n" + text
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, out, nil
+}
+
+// newInstructionBreakpoints converts registered instruction breakpoints.
+func newInstructionBreakpoints(bps []dap.InstructionBreakpoint) []InstructionBreakpoint {
+	out := make([]InstructionBreakpoint, len(bps))
+	for i, bp := range bps {
+		out[i] = InstructionBreakpoint{
+			Address:      bp.InstructionReference,
+			Offset:       bp.Offset,
+			Condition:    bp.Condition,
+			HitCondition: bp.HitCondition,
+		}
+	}
+	return out
+}
+
+// formatInstructionBreakpoints describes the registered instruction
+// breakpoints.
+func formatInstructionBreakpoints(bps []InstructionBreakpoint) string {
+	if len(bps) == 0 {
+		return "No instruction breakpoints set."
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Instruction breakpoints (%d):\n", len(bps))
+	for _, bp := range bps {
+		fmt.Fprintf(&result, "  %s", instructionLocation(bp.Address, bp.Offset))
+		if bp.Condition != "" {
+			fmt.Fprintf(&result, " if %s", bp.Condition)
+		}
+		if bp.HitCondition != "" {
+			fmt.Fprintf(&result, " (hit %s)", bp.HitCondition)
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// instructionLocation renders an instruction reference and byte offset.
+func instructionLocation(ref string, offset int) string {
+	if offset == 0 {
+		return ref
+	}
+	return fmt.Sprintf("%s%+d", ref, offset)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-dap"
+)
+
+func TestFormatInstructions(t *testing.T) {
+	got := formatInstructions([]Instruction{
+		{Address: "0x1000", Instruction: "MOVQ $0xa, 0x8(SP)", File: "main.go", Line: 7},
+		{Address: "0x1009", Instruction: "NOPL"},
+	})
+	want := `Next instructions:
+=> 0x1000  MOVQ $0xa, 0x8(SP)  ; main.go:7
+   0x1009  NOPL
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatInstructionBreakpoints(t *testing.T) {
+	var r breakpointRegistry
+	if got := formatInstructionBreakpoints(newInstructionBreakpoints(r.instructionBreakpoints())); got != "No instruction breakpoints set." {
+		t.Errorf("empty registry: got %q", got)
+	}
+	r.setInstruction(dap.InstructionBreakpoint{InstructionReference: "0x1000"})
+	r.setInstruction(dap.InstructionBreakpoint{InstructionReference: "0x1000", Offset: -4, Condition: "x > 1", HitCondition: "3"})
+	want := `Instruction breakpoints (2):
+  0x1000
+  0x1000-4 if x > 1 (hit 3)
+`
+	if got := formatInstructionBreakpoints(newInstructionBreakpoints(r.instructionBreakpoints())); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"syscall"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -13,36 +16,42 @@ import (
 var version = "dev"
 
 func main() {
+	cfg, err := loadConfig(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	// Log only to a file — never to stderr. With MCP stdio transport,
 	// stderr is a pipe to the MCP client. If the pipe buffer fills
 	// (from our logs or the DAP adapter's stderr), any write blocks
 	// the goroutine and hangs the server.
-	logPath := filepath.Join(os.TempDir(), "mcp-dap-server.log")
-	var logWriter io.Writer
-	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
+	var logWriter io.Writer = io.Discard
+	if cfg.LogLevel != "off" {
+		logFile, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 		// Discard logs if we can't open the file — do NOT fall back to stderr
-		logWriter = io.Discard
-		log.SetOutput(logWriter)
-	} else {
-		logWriter = logFile
-		log.SetOutput(logWriter)
-		defer logFile.Close()
+		if err == nil {
+			logWriter = logFile
+			defer logFile.Close()
+		}
 	}
-
-	log.Printf("mcp-dap-server starting (log file: %s)", logPath)
-
-	// Create MCP server
-	implementation := mcp.Implementation{
-		Name:    "mcp-dap-server",
-		Version: version,
+	log.SetOutput(logWriter)
+
+	log.Printf("mcp-dap-server starting (log file: %s)", cfg.LogFile)
+
+	if cfg.Transport != "stdio" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := serveHTTP(ctx, cfg, logWriter); err != nil {
+			// stderr is not the MCP connection here, so report it there too.
+			fmt.Fprintf(os.Stderr, "server error: %v\n", err)
+			log.Fatalf("server error: %v", err)
+		}
+		return
 	}
-	server := mcp.NewServer(&implementation, nil)
-
-	ds := registerTools(server, logWriter)
-	defer ds.cleanup()
 
-	registerPrompts(server)
+	server, sessions := newServer(logWriter, cfg, nil)
+	defer sessions.cleanup()
 
 	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
 		log.Fatalf("server error: %v", err)
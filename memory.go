@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultMemoryCount = 64    // bytes read by 'read-memory'
+	maxMemoryCount     = 65536 // most bytes 'read-memory' reads at once
+	hexDumpWidth       = 16    // bytes per hex dump row
+)
+
+// intFormats maps the integer formats accepted by the memory tools to their
+// size in bytes.
+var intFormats = map[string]int{
+	"int8": 1, "uint8": 1,
+	"int16": 2, "uint16": 2,
+	"int32": 4, "uint32": 4,
+	"int64": 8, "uint64": 8,
+}
+
+// MemoryParams identifies the memory the memory tools access: either an
+// address or a memoryReference, plus a byte offset.
+type MemoryParams struct {
+	SessionParam
+	Address         string  `json:"address,omitempty" mcp:"hex memory address, e.g. '0xc000012345'"`
+	MemoryReference string  `json:"memoryReference,omitempty" mcp:"memoryReference of a variable or evaluate result, or a frame's instructionPointer (alternative to address)"`
+	Offset          FlexInt `json:"offset,omitempty" mcp:"byte offset from the address, may be negative (default: 0)"`
+}
+
+// reference returns the DAP memory reference to access.
+func (p MemoryParams) reference() (string, error) {
+	switch {
+	case p.Address != "" && p.MemoryReference != "":
+		return "", fmt.Errorf("provide either address or memoryReference, not both")
+	case p.MemoryReference != "":
+		return p.MemoryReference, nil
+	case p.Address != "":
+		if _, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(p.Address), "0x"), 16, 64); err != nil {
+			return "", fmt.Errorf("invalid address %q: must be a hex address such as 0xc000012345", p.Address)
+		}
+		return p.Address, nil
+	}
+	return "", fmt.Errorf("address or memoryReference is required")
+}
+
+// ReadMemoryParams defines the parameters for reading memory.
+type ReadMemoryParams struct {
+	MemoryParams
+	Count  FlexInt `json:"count,omitempty" mcp:"number of bytes to read (default: 64)"`
+	Format string  `json:"format,omitempty" mcp:"'hex' for a hex and ASCII dump (default), or an integer type to show the bytes as: 'int8', 'uint8', 'int16', 'uint16', 'int32', 'uint32', 'int64', 'uint64'"`
+	Endian string  `json:"endian,omitempty" mcp:"byte order of integer formats: 'little' (default) or 'big'"`
+}
+
+// readMemory reads a block of the program's memory.
+func (ds *debuggerSession) readMemory(ctx context.Context, _ *mcp.CallToolRequest, params ReadMemoryParams) (*mcp.CallToolResult, *MemoryResult, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
+	if !ds.capabilities.SupportsReadMemoryRequest {
+		return nil, nil, fmt.Errorf("reading memory is not supported by this debug adapter")
+	}
+	ref, err := params.reference()
+	if err != nil {
+		return nil, nil, err
+	}
+	count := params.Count.Int()
+	if count <= 0 {
+		count = defaultMemoryCount
+	}
+	if count > maxMemoryCount {
+		return nil, nil, fmt.Errorf("count %d is too large (max %d bytes)", count, maxMemoryCount)
+	}
+	format := params.Format
+	if format == "" {
+		format = "hex"
+	}
+	size, ok := intFormats[format]
+	if format != "hex" && !ok {
+		return nil, nil, fmt.Errorf("invalid format: %s (must be 'hex' or an integer type such as 'int32' or 'uint64')", format)
+	}
+	order, err := byteOrder(params.Endian)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Printf("read-memory: reference=%s offset=%d count=%d", ref, params.Offset.Int(), count)
+
+	seq, err := ds.client.ReadMemoryRequest(ref, params.Offset.Int(), count)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := readTypedResponse[*dap.ReadMemoryResponse](ds.client, seq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read memory: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Body.Data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid memory data from debug adapter: %w", err)
+	}
+
+	out := &MemoryResult{
+		Address:         resp.Body.Address,
+		Data:            hex.EncodeToString(data),
+		UnreadableBytes: resp.Body.UnreadableBytes,
+	}
+	start, _ := parseAddress(resp.Body.Address)
+	var result strings.Builder
+	fmt.Fprintf(&result, "%d bytes at %s", len(data), resp.Body.Address)
+	if out.UnreadableBytes > 0 {
+		fmt.Fprintf(&result, " (%d more bytes unreadable)", out.UnreadableBytes)
+	}
+	result.WriteString(":\n")
+	if format == "hex" {
+		writeHexDump(&result, start, data)
+	} else {
+		out.Format = format
+		out.Values = intValues(data, format, size, order)
+		writeIntValues(&result, start, out.Values, size)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: result.String()}},
+	}, out, nil
+}
+
+// WriteMemoryParams defines the parameters for writing memory.
+type WriteMemoryParams struct {
+	MemoryParams
+	Data   string `json:"data,omitempty" mcp:"bytes to write, in hex, e.g. 'deadbeef' or 'de ad be ef'"`
+	Value  string `json:"value,omitempty" mcp:"integer to write instead of data, in decimal or 0x hex, encoded as 'format'"`
+	Format string `json:"format,omitempty" mcp:"integer type of value: 'int8', 'uint8', 'int16', 'uint16', 'int32', 'uint32', 'int64', or 'uint64'"`
+	Endian string `json:"endian,omitempty" mcp:"byte order of value: 'little' (default) or 'big'"`
+}
+
+// writeMemory writes bytes, or an encoded integer, to the program's memory.
+func (ds *debuggerSession) writeMemory(ctx context.Context, _ *mcp.CallToolRequest, params WriteMemoryParams) (*mcp.CallToolResult, *WriteMemoryResult, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
+	if !ds.capabilities.SupportsWriteMemoryRequest {
+		return nil, nil, fmt.Errorf("writing memory is not supported by this debug adapter")
+	}
+	ref, err := params.reference()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := params.bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Printf("write-memory: reference=%s offset=%d bytes=%d", ref, params.Offset.Int(), len(data))
+
+	seq, err := ds.client.WriteMemoryRequest(ref, params.Offset.Int(), base64.StdEncoding.EncodeToString(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := readTypedResponse[*dap.WriteMemoryResponse](ds.client, seq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to write memory: %w", err)
+	}
+	// Adapters may omit bytesWritten when everything was written.
+	written := resp.Body.BytesWritten
+	if written == 0 {
+		written = len(data)
+	}
+	location := ref
+	if offset := params.Offset.Int() + resp.Body.Offset; offset != 0 {
+		location = fmt.Sprintf("%s offset %d", ref, offset)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Wrote %d bytes at %s. Values shown by 'context' may be stale until the next stop; use 'evaluate' to re-read them.", written, location)}},
+	}, &WriteMemoryResult{BytesWritten: written}, nil
+}
+
+// bytes returns the bytes to write: data decoded from hex, or value
+// encoded as format.
+func (p WriteMemoryParams) bytes() ([]byte, error) {
+	if (p.Data == "") == (p.Value == "") {
+		return nil, fmt.Errorf("provide exactly one of data or value")
+	}
+	if p.Data != "" {
+		data, err := hex.DecodeString(strings.Join(strings.Fields(strings.TrimPrefix(p.Data, "0x")), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid data: %w", err)
+		}
+		return data, nil
+	}
+	size, ok := intFormats[p.Format]
+	if !ok {
+		return nil, fmt.Errorf("format is required with value and must be an integer type such as 'int32' or 'uint64'")
+	}
+	order, err := byteOrder(p.Endian)
+	if err != nil {
+		return nil, err
+	}
+	return encodeInt(p.Value, p.Format, size, order)
+}
+
+// encodeInt encodes an integer in decimal or 0x hex as size bytes.
+func encodeInt(value, format string, size int, order binary.ByteOrder) ([]byte, error) {
+	var u uint64
+	if strings.HasPrefix(format, "uint") {
+		v, err := strconv.ParseUint(value, 0, size*8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: %w", format, value, err)
+		}
+		u = v
+	} else {
+		v, err := strconv.ParseInt(value, 0, size*8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: %w", format, value, err)
+		}
+		u = uint64(v)
+	}
+	buf := make([]byte, 8)
+	switch size {
+	case 1:
+		buf[0] = byte(u)
+	case 2:
+		order.PutUint16(buf, uint16(u))
+	case 4:
+		order.PutUint32(buf, uint32(u))
+	case 8:
+		order.PutUint64(buf, u)
+	}
+	return buf[:size], nil
+}
+
+// byteOrder parses an endianness parameter.
+func byteOrder(endian string) (binary.ByteOrder, error) {
+	switch endian {
+	case "", "little":
+		return binary.LittleEndian, nil
+	case "big":
+		return binary.BigEndian, nil
+	}
+	return nil, fmt.Errorf("invalid endian: %s (must be 'little' or 'big')", endian)
+}
+
+// parseAddress parses a hex address as returned by adapters.
+func parseAddress(addr string) (uint64, bool) {
+	a, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(addr), "0x"), 16, 64)
+	return a, err == nil
+}
+
+// writeHexDump renders data as rows of hex bytes with their ASCII text,
+// each row starting with its address.
+func writeHexDump(result *strings.Builder, start uint64, data []byte) {
+	for row := 0; row < len(data); row += hexDumpWidth {
+		end := min(row+hexDumpWidth, len(data))
+		fmt.Fprintf(result, "  %#016x  ", start+uint64(row))
+		for i := row; i < row+hexDumpWidth; i++ {
+			if i < end {
+				fmt.Fprintf(result, "%02x ", data[i])
+			} else {
+				result.WriteString("   ")
+			}
+			if i == row+hexDumpWidth/2-1 {
+				result.WriteByte(' ')
+			}
+		}
+		result.WriteString(" |")
+		for _, b := range data[row:end] {
+			if b < 0x20 || b > 0x7e {
+				b = '.'
+			}
+			result.WriteByte(b)
+		}
+		result.WriteString("|\n")
+	}
+}
+
+// intValues interprets data as integers of format, in decimal. Trailing
+// bytes that do not fill a whole integer are ignored.
+func intValues(data []byte, format string, size int, order binary.ByteOrder) []string {
+	signed := !strings.HasPrefix(format, "uint")
+	values := make([]string, 0, len(data)/size)
+	for i := 0; i+size <= len(data); i += size {
+		var u uint64
+		switch size {
+		case 1:
+			u = uint64(data[i])
+		case 2:
+			u = uint64(order.Uint16(data[i:]))
+		case 4:
+			u = uint64(order.Uint32(data[i:]))
+		case 8:
+			u = order.Uint64(data[i:])
+		}
+		if signed {
+			// Sign-extend from the integer's width.
+			shift := 64 - size*8
+			values = append(values, strconv.FormatInt(int64(u<<shift)>>shift, 10))
+		} else {
+			values = append(values, strconv.FormatUint(u, 10))
+		}
+	}
+	return values
+}
+
+// writeIntValues renders integers of size bytes in rows of hexDumpWidth
+// bytes, each row starting with its address.
+func writeIntValues(result *strings.Builder, start uint64, values []string, size int) {
+	perRow := hexDumpWidth / size
+	for row := 0; row < len(values); row += perRow {
+		end := min(row+perRow, len(values))
+		fmt.Fprintf(result, "  %#016x  %s\n", start+uint64(row*size), strings.Join(values[row:end], "  "))
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/binary"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriteHexDump(t *testing.T) {
+	var result strings.Builder
+	writeHexDump(&result, 0x1000, []byte("Hello, world!\n\x00\x01ab"))
+	want := `  0x0000000000001000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 01  |Hello, world!...|
+  0x0000000000001010  61 62                                             |ab|
+`
+	if result.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result.String(), want)
+	}
+}
+
+func TestIntValues(t *testing.T) {
+	data := []byte{0xff, 0xff, 0xff, 0xff, 0x2a, 0x00, 0x00, 0x00, 0x01}
+	tests := []struct {
+		format string
+		order  binary.ByteOrder
+		want   []string
+	}{
+		{"int32", binary.LittleEndian, []string{"-1", "42"}},
+		{"uint32", binary.LittleEndian, []string{"4294967295", "42"}},
+		{"int32", binary.BigEndian, []string{"-1", "704643072"}},
+		{"int16", binary.LittleEndian, []string{"-1", "-1", "42", "0"}},
+		{"uint8", binary.LittleEndian, []string{"255", "255", "255", "255", "42", "0", "0", "0", "1"}},
+		{"int64", binary.LittleEndian, []string{"184683593727"}},
+	}
+	for _, tt := range tests {
+		if got := intValues(data, tt.format, intFormats[tt.format], tt.order); !slices.Equal(got, tt.want) {
+			t.Errorf("intValues(%s, %v) = %v, want %v", tt.format, tt.order, got, tt.want)
+		}
+	}
+
+	var result strings.Builder
+	writeIntValues(&result, 0x10, []string{"1", "2", "3", "4", "5"}, 4)
+	want := "  0x0000000000000010  1  2  3  4\n  0x0000000000000020  5\n"
+	if result.String() != want {
+		t.Errorf("writeIntValues:\ngot:\n%s\nwant:\n%s", result.String(), want)
+	}
+}
+
+func TestWriteMemoryBytes(t *testing.T) {
+	tests := []struct {
+		params  WriteMemoryParams
+		want    []byte
+		wantErr bool
+	}{
+		{params: WriteMemoryParams{Data: "de ad BE ef"}, want: []byte{0xde, 0xad, 0xbe, 0xef}},
+		{params: WriteMemoryParams{Data: "0x0102"}, want: []byte{1, 2}},
+		{params: WriteMemoryParams{Value: "-2", Format: "int16"}, want: []byte{0xfe, 0xff}},
+		{params: WriteMemoryParams{Value: "0x01020304", Format: "uint32", Endian: "big"}, want: []byte{1, 2, 3, 4}},
+		{params: WriteMemoryParams{Value: "256", Format: "uint8"}, wantErr: true},
+		{params: WriteMemoryParams{Value: "1"}, wantErr: true},
+		{params: WriteMemoryParams{Data: "abc"}, wantErr: true},
+		{params: WriteMemoryParams{Data: "00", Value: "1", Format: "int8"}, wantErr: true},
+		{params: WriteMemoryParams{Value: "1", Format: "int32", Endian: "middle"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.params.bytes()
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("bytes(%+v) = %v, %v; want %v, error %v", tt.params, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMemoryReference(t *testing.T) {
+	if ref, err := (MemoryParams{Address: "0xC000012345"}).reference(); err != nil || ref != "0xC000012345" {
+		t.Errorf("reference with address = %q, %v", ref, err)
+	}
+	if ref, err := (MemoryParams{MemoryReference: "var-3"}).reference(); err != nil || ref != "var-3" {
+		t.Errorf("reference with memoryReference = %q, %v", ref, err)
+	}
+	for _, p := range []MemoryParams{{}, {Address: "main.x"}, {Address: "0x10", MemoryReference: "0x10"}} {
+		if _, err := p.reference(); err == nil {
+			t.Errorf("reference(%+v) succeeded, want error", p)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// defaultOutputLines is the number of output lines retained per session.
+// Older lines are dropped once the buffer is full.
+const defaultOutputLines = 1000
+
+// outputLine is a single line of debuggee or adapter output.
+type outputLine struct {
+	seq      int    // monotonically increasing line number within the session
+	category string // DAP output category: "stdout", "stderr", "console", ...
+	text     string // line contents without the trailing newline
+}
+
+// outputBuffer is a bounded ring buffer of output received via DAP
+// OutputEvents. Output arrives in arbitrary chunks, so incomplete trailing
+// lines are held per category until their newline arrives or they are read.
+//
+// The buffer tracks a read cursor so that each call to the 'output' tool
+// returns only what arrived since the previous call.
+type outputBuffer struct {
+	mu       sync.Mutex
+	capacity int
+	lines    []outputLine      // ring storage, len(lines) <= capacity
+	head     int               // index of the oldest line in lines
+	nextSeq  int               // seq to assign to the next complete line
+	readSeq  int               // seq of the first line not yet returned by read
+	partial  map[string]string // incomplete trailing line per category
+	catOrder []string          // categories in partial, in arrival order
+}
+
+// newOutputBuffer returns an empty buffer that retains up to capacity lines.
+func newOutputBuffer(capacity int) *outputBuffer {
+	return &outputBuffer{capacity: capacity, partial: make(map[string]string)}
+}
+
+// reset discards all buffered output and rewinds the read cursor.
+func (b *outputBuffer) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lines = nil
+	b.head = 0
+	b.nextSeq = 0
+	b.readSeq = 0
+	b.partial = make(map[string]string)
+	b.catOrder = nil
+}
+
+// write records a chunk of output for category. Empty categories are
+// recorded as "console", the DAP default; telemetry is ignored.
+func (b *outputBuffer) write(category, text string) {
+	switch category {
+	case "":
+		category = "console"
+	case "telemetry":
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	prev, ok := b.partial[category]
+	text = prev + text
+	for {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			break
+		}
+		b.appendLine(category, strings.TrimSuffix(text[:i], "\r"))
+		text = text[i+1:]
+	}
+	if text == "" {
+		if ok {
+			delete(b.partial, category)
+			b.removeCategory(category)
+		}
+		return
+	}
+	if !ok {
+		b.catOrder = append(b.catOrder, category)
+	}
+	b.partial[category] = text
+}
+
+// appendLine adds a complete line, evicting the oldest when full.
+// Callers must hold b.mu.
+func (b *outputBuffer) appendLine(category, text string) {
+	line := outputLine{seq: b.nextSeq, category: category, text: text}
+	b.nextSeq++
+	if len(b.lines) < b.capacity {
+		b.lines = append(b.lines, line)
+		return
+	}
+	b.lines[b.head] = line
+	b.head = (b.head + 1) % b.capacity
+}
+
+// removeCategory drops category from catOrder. Callers must hold b.mu.
+func (b *outputBuffer) removeCategory(category string) {
+	for i, c := range b.catOrder {
+		if c == category {
+			b.catOrder = append(b.catOrder[:i], b.catOrder[i+1:]...)
+			return
+		}
+	}
+}
+
+// flushPartial turns any incomplete trailing lines into complete lines, so
+// that output without a final newline (e.g. a prompt) is not withheld.
+// Callers must hold b.mu.
+func (b *outputBuffer) flushPartial() {
+	for _, c := range b.catOrder {
+		b.appendLine(c, b.partial[c])
+		delete(b.partial, c)
+	}
+	b.catOrder = nil
+}
+
+// oldestSeq returns the seq of the oldest retained line. Callers must hold b.mu.
+func (b *outputBuffer) oldestSeq() int {
+	if len(b.lines) == 0 {
+		return b.nextSeq
+	}
+	return b.lines[b.head].seq
+}
+
+// unread returns the number of complete lines received since the last read,
+// including lines that have since been dropped from the buffer.
+func (b *outputBuffer) unread() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextSeq - b.readSeq
+}
+
+// read returns the lines received since the last read that match category
+// (if non-empty) and pattern (if non-nil), and advances the read cursor past
+// every line, matching or not. dropped reports how many unread lines were
+// evicted before they could be returned.
+func (b *outputBuffer) read(category string, pattern *regexp.Regexp) (lines []outputLine, dropped int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushPartial()
+
+	oldest := b.oldestSeq()
+	if b.readSeq < oldest {
+		dropped = oldest - b.readSeq
+		b.readSeq = oldest
+	}
+	for i := range b.lines {
+		line := b.lines[(b.head+i)%len(b.lines)]
+		if line.seq < b.readSeq {
+			continue
+		}
+		if category != "" && line.category != category {
+			continue
+		}
+		if pattern != nil && !pattern.MatchString(line.text) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	b.readSeq = b.nextSeq
+	return lines, dropped
+}
+
+// snapshot returns the retained complete lines without advancing the read
+// cursor.
+func (b *outputBuffer) snapshot() OutputResult {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := OutputResult{Lines: make([]OutputLine, len(b.lines))}
+	for i := range b.lines {
+		line := b.lines[(b.head+i)%len(b.lines)]
+		out.Lines[i] = OutputLine{Category: line.category, Text: line.text}
+	}
+	return out
+}
+
+// OutputParams defines the parameters for reading program output.
+type OutputParams struct {
+	SessionParam
+	Category string `json:"category,omitempty" mcp:"only return output of this category: 'stdout', 'stderr', or 'console' (default: all)"`
+	Pattern  string `json:"pattern,omitempty" mcp:"only return lines matching this regular expression"`
+}
+
+// readOutput returns the program output received since the previous call.
+func (ds *debuggerSession) readOutput(ctx context.Context, _ *mcp.CallToolRequest, params OutputParams) (*mcp.CallToolResult, *OutputResult, error) {
+	var pattern *regexp.Regexp
+	if params.Pattern != "" {
+		var err error
+		pattern, err = regexp.Compile(params.Pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+
+	lines, dropped := ds.output.read(params.Category, pattern)
+	out := &OutputResult{Lines: make([]OutputLine, len(lines)), Dropped: dropped}
+
+	var result strings.Builder
+	if dropped > 0 {
+		fmt.Fprintf(&result, "(%d earlier lines dropped; only the last %d lines are kept)\n", dropped, ds.output.capacity)
+	}
+	if len(lines) == 0 {
+		result.WriteString("No new output")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: result.String()}},
+		}, out, nil
+	}
+	for i, line := range lines {
+		out.Lines[i] = OutputLine{Category: line.category, Text: line.text}
+		fmt.Fprintf(&result, "[%s] %s\n", line.category, line.text)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: result.String()}},
+	}, out, nil
+}
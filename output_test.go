@@ -0,0 +1,85 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestOutputBufferSplitsLines(t *testing.T) {
+	b := newOutputBuffer(10)
+	b.write("stdout", "hello, ")
+	b.write("stderr", "warn\n")
+	b.write("stdout", "world\nsecond\r\nthird")
+
+	if n := b.unread(); n != 3 {
+		t.Errorf("expected 3 complete unread lines, got: %d", n)
+	}
+
+	lines, dropped := b.read("", nil)
+	if dropped != 0 {
+		t.Errorf("expected no dropped lines, got: %d", dropped)
+	}
+	want := []outputLine{
+		{seq: 0, category: "stderr", text: "warn"},
+		{seq: 1, category: "stdout", text: "hello, world"},
+		{seq: 2, category: "stdout", text: "second"},
+		{seq: 3, category: "stdout", text: "third"}, // partial line flushed on read
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got: %v", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: expected %+v, got %+v", i, want[i], lines[i])
+		}
+	}
+
+	if lines, _ := b.read("", nil); len(lines) != 0 {
+		t.Errorf("expected no new lines on second read, got: %v", lines)
+	}
+}
+
+func TestOutputBufferFilters(t *testing.T) {
+	b := newOutputBuffer(10)
+	b.write("stdout", "request 1 ok\nrequest 2 failed\n")
+	b.write("stderr", "request 3 failed\n")
+	b.write("", "adapter message\n")
+	b.write("telemetry", "ignored\n")
+
+	lines, _ := b.read("stdout", regexp.MustCompile("failed"))
+	if len(lines) != 1 || lines[0].text != "request 2 failed" {
+		t.Errorf("expected only the failed stdout line, got: %v", lines)
+	}
+
+	// Filtered-out lines are consumed too.
+	if n := b.unread(); n != 0 {
+		t.Errorf("expected read to consume all lines, got %d unread", n)
+	}
+
+	b.write("", "more\n")
+	lines, _ = b.read("console", nil)
+	if len(lines) != 1 || lines[0].text != "more" {
+		t.Errorf("expected empty category to be recorded as console, got: %v", lines)
+	}
+}
+
+func TestOutputBufferDropsOldest(t *testing.T) {
+	b := newOutputBuffer(3)
+	b.write("stdout", "1\n2\n3\n4\n5\n")
+
+	if n := b.unread(); n != 5 {
+		t.Errorf("expected unread to count dropped lines, got: %d", n)
+	}
+	lines, dropped := b.read("", nil)
+	if dropped != 2 {
+		t.Errorf("expected 2 dropped lines, got: %d", dropped)
+	}
+	if len(lines) != 3 || lines[0].text != "3" || lines[2].text != "5" {
+		t.Errorf("expected lines 3..5, got: %v", lines)
+	}
+
+	b.reset()
+	if n := b.unread(); n != 0 {
+		t.Errorf("expected no unread lines after reset, got: %d", n)
+	}
+}
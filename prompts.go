@@ -14,7 +14,7 @@ func registerPrompts(server *mcp.Server) {
 		Description: "Structured workflow for debugging a program from source code",
 		Arguments: []*mcp.PromptArgument{
 			{Name: "path", Required: true, Description: "Path to the source file or directory to debug"},
-			{Name: "language", Required: false, Description: "Language: 'go' (default) or 'c'/'cpp'"},
+			{Name: "language", Required: false, Description: "Language: 'go' (default), 'c'/'cpp', or 'python'"},
 			{Name: "breakpoints", Required: false, Description: "Comma-separated file:line pairs, e.g. 'main.go:42,server.go:100'"},
 		},
 	}, promptDebugSource)
@@ -68,6 +68,9 @@ func promptDebugSource(_ context.Context, req *mcp.GetPromptRequest) (*mcp.GetPr
 > - C++: `+"`"+`g++ -g -O0 -o myprogram %s`+"`"+`
 > Then use 'binary' mode with the compiled output path.`, path, path)
 	}
+	if language == "python" {
+		debugger = "debugpy"
+	}
 
 	bpSection := ""
 	if breakpoints != "" {
@@ -188,8 +191,11 @@ Call: `+"`"+`stop()`+"`"+`
 		mode, path, debugger,
 		bpSection,
 		func() string {
-			if debugger == "delve" {
+			switch debugger {
+			case "delve":
 				return "dlv"
+			case "debugpy":
+				return "debugpy (pip install debugpy)"
 			}
 			return "gdb (native DAP)"
 		}(),
@@ -459,9 +465,10 @@ func promptDebugBinary(_ context.Context, req *mcp.GetPromptRequest) (*mcp.GetPr
 	path := req.Params.Arguments["path"]
 
 	// Infer likely language/debugger from path or note that both are supported
-	debuggerNote := `Use 'delve' for Go binaries, 'gdb' for C/C++/Rust binaries.
+	debuggerNote := `Use 'delve' for Go binaries, 'gdb' or 'lldb' for C/C++/Rust binaries.
 > - Go binary: `+"`"+`debug(mode="binary", path="...", debugger="delve")`+"`"+`
-> - C/C++ binary: `+"`"+`debug(mode="binary", path="...", debugger="gdb")`+"`"+``
+> - C/C++ binary: `+"`"+`debug(mode="binary", path="...", debugger="gdb")`+"`"+`
+> - clang/LLVM-built binary (C/C++/Rust/Swift): `+"`"+`debug(mode="binary", path="...", debugger="lldb")`+"`"+``
 
 	content := fmt.Sprintf(`## Binary / Assembly-Level Debug Session
 
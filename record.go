@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// recordRun records a run of the program at path with rr, for Delve to
+// replay in 'replay' mode. The program runs with opts' arguments,
+// environment and working directory. A Go source file or package directory
+// is built first, to opts.Output if set, with opts.BuildFlags and
+// optimizations disabled as Delve's 'source' mode does, so opts.BuildFlags
+// may not set -gcflags. The trace is saved to traceDir, made absolute, or
+// to a new temporary directory if traceDir is empty, and kept after the
+// session ends so that the same run can be replayed again. The program's
+// output is recorded in ds.output.
+func (ds *debuggerSession) recordRun(ctx context.Context, path string, opts LaunchOptions, traceDir string) (string, error) {
+	buildFlags := strings.Fields(opts.BuildFlags)
+	for _, f := range buildFlags {
+		// A later -gcflags would silently override the one disabling
+		// optimizations.
+		if strings.HasPrefix(f, "-gcflags") || strings.HasPrefix(f, "--gcflags") {
+			return "", fmt.Errorf("record mode builds with -gcflags='all=-N -l'; remove -gcflags from buildFlags")
+		}
+	}
+	rrPath, err := exec.LookPath("rr")
+	if err != nil {
+		return "", fmt.Errorf("rr not found in PATH. Install rr (https://rr-project.org) to record runs")
+	}
+	if traceDir == "" {
+		dir, err := os.MkdirTemp("", "mcp-dap-rr-")
+		if err != nil {
+			return "", err
+		}
+		traceDir = filepath.Join(dir, "trace")
+	} else {
+		// rr runs in opts.Cwd, and the replay in Delve's directory.
+		if traceDir, err = filepath.Abs(traceDir); err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(traceDir); err == nil {
+			return "", fmt.Errorf("traceDir %s already exists; rr records into a new directory", traceDir)
+		}
+	}
+
+	program, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if isGoSource(path) {
+		// Keep the binary next to the trace by default: the replay reads
+		// its debug information from the path it was recorded at.
+		if err := os.MkdirAll(filepath.Dir(traceDir), 0755); err != nil {
+			return "", err
+		}
+		program = strings.TrimSuffix(traceDir, string(filepath.Separator)) + ".bin"
+		if opts.Output != "" {
+			if program, err = filepath.Abs(opts.Output); err != nil {
+				return "", err
+			}
+		}
+		buildArgs := append([]string{"build"}, buildFlags...)
+		buildArgs = append(buildArgs, "-gcflags=all=-N -l", "-o", program, filepath.Base(path))
+		build := exec.CommandContext(ctx, "go", buildArgs...)
+		build.Dir = filepath.Dir(path)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			build.Args[len(build.Args)-1] = "."
+			build.Dir = path
+		}
+		if out, err := build.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("failed to build %s: %w\n%s", path, err, out)
+		}
+	}
+
+	record := exec.CommandContext(ctx, rrPath, append([]string{"record", "-o", traceDir, program}, opts.Args...)...)
+	record.Dir = opts.Cwd
+	if len(opts.Env) > 0 {
+		record.Env = append(os.Environ(), environ(opts.Env)...)
+	}
+	record.Stdout = outputWriter{ds.output, "stdout"}
+	record.Stderr = outputWriter{ds.output, "stderr"}
+	// A failing run is usually the one worth replaying, so only a missing
+	// trace is an error.
+	runErr := record.Run()
+	if _, err := os.Stat(traceDir); err != nil {
+		if runErr == nil {
+			runErr = err
+		}
+		return "", fmt.Errorf("failed to record %s: %w", path, runErr)
+	}
+	return traceDir, nil
+}
+
+// isGoSource reports whether path is a Go source file or a directory, which
+// recordRun builds before recording.
+func isGoSource(path string) bool {
+	if strings.HasSuffix(path, ".go") {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// outputWriter records writes in an outputBuffer under a fixed category.
+type outputWriter struct {
+	buf      *outputBuffer
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.buf.write(w.category, string(p))
+	return len(p), nil
+}
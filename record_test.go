@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsGoSource(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "prog")
+	if err := os.WriteFile(binary, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		filepath.Join(dir, "main.go"): true,
+		dir:                           true,
+		binary:                        false,
+		filepath.Join(dir, "missing"): false,
+	} {
+		if got := isGoSource(path); got != want {
+			t.Errorf("isGoSource(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestOutputWriter(t *testing.T) {
+	buf := newOutputBuffer(10)
+	w := outputWriter{buf, "stderr"}
+	w.Write([]byte("rr: Saving execution"))
+	w.Write([]byte(" to trace directory\n"))
+	lines, _ := buf.read("stderr", nil)
+	if len(lines) != 1 || lines[0].text != "rr: Saving execution to trace directory" {
+		t.Errorf("got %+v, want one line joining both writes", lines)
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Resource URI templates. {session} is a session name as listed by
+// 'sessions', e.g. dap://default/breakpoints.
+const (
+	sourceResourceTemplate          = "dap://{session}/source{+path}"
+	sourceReferenceResourceTemplate = "dap://{session}/source-reference/{sourceReference}"
+	stackResourceTemplate           = "dap://{session}/stack/{threadId}"
+	breakpointsResourceTemplate     = "dap://{session}/breakpoints"
+	outputResourceTemplate          = "dap://{session}/output"
+)
+
+// resourceSubscriptions records the resource URIs clients have subscribed
+// to, so that a session can notify all of its subscribed resources when the
+// debuggee stops. The MCP server tracks which client subscribed to what;
+// this only tracks which URIs have any subscriber.
+type resourceSubscriptions struct {
+	mu   sync.Mutex
+	uris map[string]int // subscriber count per URI
+}
+
+// newResourceSubscriptions returns an empty subscription set.
+func newResourceSubscriptions() *resourceSubscriptions {
+	return &resourceSubscriptions{uris: make(map[string]int)}
+}
+
+// serverOptions returns MCP server options that enable resource
+// subscriptions and record them in subs.
+func (subs *resourceSubscriptions) serverOptions() *mcp.ServerOptions {
+	return &mcp.ServerOptions{
+		SubscribeHandler:   subs.subscribe,
+		UnsubscribeHandler: subs.unsubscribe,
+	}
+}
+
+// subscribe records a subscription to a session resource.
+func (subs *resourceSubscriptions) subscribe(_ context.Context, req *mcp.SubscribeRequest) error {
+	if _, _, err := parseResourceURI(req.Params.URI); err != nil {
+		return err
+	}
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+	subs.uris[req.Params.URI]++
+	return nil
+}
+
+// unsubscribe forgets a subscription.
+func (subs *resourceSubscriptions) unsubscribe(_ context.Context, req *mcp.UnsubscribeRequest) error {
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+	if subs.uris[req.Params.URI] <= 1 {
+		delete(subs.uris, req.Params.URI)
+	} else {
+		subs.uris[req.Params.URI]--
+	}
+	return nil
+}
+
+// sessionURIs returns the subscribed URIs of the named session.
+func (subs *resourceSubscriptions) sessionURIs(session string) []string {
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+	var uris []string
+	for uri := range subs.uris {
+		if name, _, err := parseResourceURI(uri); err == nil && name == session {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// registerResources registers the session resource templates with the MCP
+// server. subs, if not nil, must be the subscriptions the server was created
+// with; sessions then notify subscribers when the debuggee stops.
+func registerResources(server *mcp.Server, m *sessionManager, subs *resourceSubscriptions) {
+	m.subscriptions = subs
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "source",
+		URITemplate: sourceResourceTemplate,
+		Description: "A local source file of the debugged program, by absolute path, e.g. dap://default/source/home/me/app/main.go.",
+		MIMEType:    "text/plain",
+	}, m.readResource)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "source-reference",
+		URITemplate: sourceReferenceResourceTemplate,
+		Description: "Source provided by the debug adapter for a stack frame with a sourceReference but no local file, such as generated or runtime code.",
+		MIMEType:    "text/plain",
+	}, m.readResource)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "stack",
+		URITemplate: stackResourceTemplate,
+		Description: "Stack trace of a thread of the stopped program, as JSON.",
+		MIMEType:    "application/json",
+	}, m.readResource)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "breakpoints",
+		URITemplate: breakpointsResourceTemplate,
+		Description: "Breakpoints, watches and exception filters set in a session, as JSON.",
+		MIMEType:    "application/json",
+	}, m.readResource)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "output",
+		URITemplate: outputResourceTemplate,
+		Description: "Recent program and debugger output of a session, as JSON. Reading it does not consume output from the 'output' tool.",
+		MIMEType:    "application/json",
+	}, m.readResource)
+}
+
+// parseResourceURI splits a session resource URI into the session name and
+// the path after it, e.g. "/stack/1".
+func parseResourceURI(uri string) (session, path string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme != "dap" || u.Host == "" {
+		return "", "", fmt.Errorf("not a debug session resource: %s", uri)
+	}
+	return u.Host, u.Path, nil
+}
+
+// readResource reads a session resource.
+func (m *sessionManager) readResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	name, path, err := parseResourceURI(uri)
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	ds, err := m.lookup(name)
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	var contents *mcp.ResourceContents
+	switch {
+	case strings.HasPrefix(path, "/source/"):
+		contents, err = readSourceFile(strings.TrimPrefix(path, "/source"))
+	case strings.HasPrefix(path, "/source-reference/"):
+		ref, perr := strconv.Atoi(strings.TrimPrefix(path, "/source-reference/"))
+		if perr != nil {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		contents, err = ds.readSourceReference(ref)
+	case strings.HasPrefix(path, "/stack/"):
+		threadID, perr := strconv.Atoi(strings.TrimPrefix(path, "/stack/"))
+		if perr != nil {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		contents, err = ds.readStack(threadID)
+	case path == "/breakpoints":
+		contents, err = ds.readBreakpoints()
+	case path == "/output":
+		contents, err = jsonContents(ds.output.snapshot())
+	default:
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{contents}}, nil
+}
+
+// jsonContents encodes v as JSON resource contents.
+func jsonContents(v any) (*mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ResourceContents{MIMEType: "application/json", Text: string(data)}, nil
+}
+
+// readSourceFile reads a local source file.
+func readSourceFile(path string) (*mcp.ResourceContents, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read source: %w", err)
+	}
+	return &mcp.ResourceContents{MIMEType: "text/plain", Text: string(data)}, nil
+}
+
+// readSourceReference fetches source that only the debug adapter has.
+func (ds *debuggerSession) readSourceReference(ref int) (*mcp.ResourceContents, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.client == nil {
+		return nil, fmt.Errorf("debugger not started")
+	}
+	seq, err := ds.client.SourceRequest(ref)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := readTypedResponse[*dap.SourceResponse](ds.client, seq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get source: %w", err)
+	}
+	mimeType := resp.Body.MimeType
+	if mimeType == "" {
+		mimeType = "text/plain"
+	}
+	return &mcp.ResourceContents{MIMEType: mimeType, Text: resp.Body.Content}, nil
+}
+
+// readStack returns the stack trace of threadID.
+func (ds *debuggerSession) readStack(threadID int) (*mcp.ResourceContents, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.client == nil {
+		return nil, fmt.Errorf("debugger not started")
+	}
+	if ds.running != nil {
+		return nil, errRunning
+	}
+	frames, err := ds.stackTrace(threadID, ds.config.MaxFrames)
+	if err != nil {
+		return nil, err
+	}
+	return jsonContents(StackResult{ThreadID: threadID, StackTrace: frames})
+}
+
+// readBreakpoints lists the session's breakpoints.
+func (ds *debuggerSession) readBreakpoints() (*mcp.ResourceContents, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	out := BreakpointsResult{
+		Breakpoints:  []BreakpointSpec{},
+		Instructions: newInstructionBreakpoints(ds.breakpoints.instructionBreakpoints()),
+		Watches:      newWatchResult(ds.breakpoints.watches()).Watches,
+		Exceptions:   []ExceptionFilter{},
+	}
+	for _, file := range ds.breakpoints.files() {
+		for _, bp := range ds.breakpoints.sourceBreakpoints(file) {
+			out.Breakpoints = append(out.Breakpoints, BreakpointSpec{
+				File:         file,
+				Line:         bp.Line,
+				Condition:    bp.Condition,
+				HitCondition: bp.HitCondition,
+				LogMessage:   bp.LogMessage,
+			})
+		}
+	}
+	for _, bp := range ds.breakpoints.functionBreakpoints() {
+		out.Breakpoints = append(out.Breakpoints, BreakpointSpec{
+			Function:     bp.Name,
+			Condition:    bp.Condition,
+			HitCondition: bp.HitCondition,
+		})
+	}
+	for _, opt := range ds.breakpoints.exceptionFilters() {
+		out.Exceptions = append(out.Exceptions, ExceptionFilter{Filter: opt.FilterId, Enabled: true, Condition: opt.Condition})
+	}
+	return jsonContents(out)
+}
+
+// notifyResources tells subscribers that every resource of the session may
+// have changed. It runs on the DAP client's read loop, so it sends the
+// notifications in the background.
+func (ds *debuggerSession) notifyResources() {
+	if ds.manager == nil || ds.manager.subscriptions == nil {
+		return
+	}
+	uris := ds.manager.subscriptions.sessionURIs(ds.name)
+	if len(uris) == 0 {
+		return
+	}
+	server := ds.manager.server
+	go func() {
+		for _, uri := range uris {
+			if err := server.ResourceUpdated(context.Background(), &mcp.ResourceUpdatedNotificationParams{URI: uri}); err != nil {
+				log.Printf("notifyResources: %s: %v", uri, err)
+			}
+		}
+	}()
+}
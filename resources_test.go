@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestParseResourceURI(t *testing.T) {
+	tests := []struct {
+		uri, session, path string
+		ok                 bool
+	}{
+		{"dap://default/breakpoints", "default", "/breakpoints", true},
+		{"dap://a/stack/1", "a", "/stack/1", true},
+		{"dap://default/source/home/me/main.go", "default", "/source/home/me/main.go", true},
+		{"dap://default/source/my%20dir/main.go", "default", "/source/my dir/main.go", true},
+		{"file:///etc/passwd", "", "", false},
+		{"dap:///output", "", "", false},
+	}
+	for _, tt := range tests {
+		session, path, err := parseResourceURI(tt.uri)
+		if (err == nil) != tt.ok || session != tt.session || path != tt.path {
+			t.Errorf("parseResourceURI(%q) = %q, %q, %v; want %q, %q, ok=%v", tt.uri, session, path, err, tt.session, tt.path, tt.ok)
+		}
+	}
+}
+
+// connectResourceClient starts a server with resources and a session named
+// "default", and connects a client that records resource updates.
+func connectResourceClient(t *testing.T) (*debuggerSession, *mcp.ClientSession, <-chan string) {
+	t.Helper()
+	subs := newResourceSubscriptions()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, subs.serverOptions())
+	m := registerTools(server, io.Discard, defaultConfig())
+	registerResources(server, m, subs)
+
+	ds := &debuggerSession{name: "default", manager: m, config: m.config, lastFrameID: -1, output: newOutputBuffer(10)}
+	m.sessions["default"] = ds
+	m.order = []string{"default"}
+
+	updated := make(chan string, 10)
+	client := mcp.NewClient(&mcp.Implementation{Name: "client"}, &mcp.ClientOptions{
+		ResourceUpdatedHandler: func(_ context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+			updated <- req.Params.URI
+		},
+	})
+	ct, st := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(context.Background(), st, nil); err != nil {
+		t.Fatal(err)
+	}
+	session, err := client.Connect(context.Background(), ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return ds, session, updated
+}
+
+func TestReadResources(t *testing.T) {
+	ds, session, _ := connectResourceClient(t)
+	ctx := context.Background()
+
+	ds.breakpoints.setSource("/app/main.go", dap.SourceBreakpoint{Line: 7, Condition: "i > 3"})
+	ds.breakpoints.setFunction(dap.FunctionBreakpoint{Name: "main.run"})
+	ds.output.write("stdout", "hello\n")
+
+	res, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: "dap://default/breakpoints"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bps BreakpointsResult
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), &bps); err != nil {
+		t.Fatal(err)
+	}
+	want := []BreakpointSpec{{File: "/app/main.go", Line: 7, Condition: "i > 3"}, {Function: "main.run"}}
+	if !slices.Equal(bps.Breakpoints, want) {
+		t.Errorf("breakpoints = %+v, want %+v", bps.Breakpoints, want)
+	}
+
+	res, err = session.ReadResource(ctx, &mcp.ReadResourceParams{URI: "dap://default/output"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out OutputResult
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Lines) != 1 || out.Lines[0].Text != "hello" {
+		t.Errorf("output = %+v, want the line 'hello'", out.Lines)
+	}
+	if n := ds.output.unread(); n != 1 {
+		t.Errorf("reading the output resource consumed output: %d unread lines, want 1", n)
+	}
+
+	src := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(src, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = session.ReadResource(ctx, &mcp.ReadResourceParams{URI: "dap://default/source" + filepath.ToSlash(src)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Contents[0].Text != "package main\n" || res.Contents[0].MIMEType != "text/plain" {
+		t.Errorf("source = %q (%s), want the file contents as text/plain", res.Contents[0].Text, res.Contents[0].MIMEType)
+	}
+
+	if _, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: "dap://other/breakpoints"}); err == nil {
+		t.Error("reading a resource of an unknown session succeeded, want error")
+	}
+}
+
+func TestResourceUpdatedOnStop(t *testing.T) {
+	ds, session, updated := connectResourceClient(t)
+	ctx := context.Background()
+
+	if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: "dap://default/stack/1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: "dap://other/output"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ds.handleEvent(&dap.StoppedEvent{Body: dap.StoppedEventBody{Reason: "breakpoint", ThreadId: 1}})
+	select {
+	case uri := <-updated:
+		if uri != "dap://default/stack/1" {
+			t.Errorf("got update for %s, want dap://default/stack/1", uri)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no resource update after the program stopped")
+	}
+	select {
+	case uri := <-updated:
+		t.Errorf("unexpected update for %s", uri)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Structured tool results.
+//
+// Every session tool returns one of these as its structured content, and
+// the go-sdk publishes the matching output schema. The text content is
+// rendered from the same values for human readers; clients should read the
+// structured fields rather than parse the text, whose wording may change.
+//
+// The output schemas are inferred from these types, and schema inference
+// rejects recursive types, so nested data such as variable trees and inner
+// exceptions is flattened into lists with a depth.
+
+// StackFrame is one frame of a stack trace.
+type StackFrame struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	File               string `json:"file,omitempty"`
+	Line               int    `json:"line,omitempty"`
+	InstructionPointer string `json:"instructionPointer,omitempty"`
+	Runtime            bool   `json:"runtime,omitempty"`         // frame is in runtime or library code
+	SourceReference    int    `json:"sourceReference,omitempty"` // set if the adapter provides the source; see the source-reference resource
+}
+
+// newStackFrame converts a DAP stack frame.
+func newStackFrame(f dap.StackFrame) StackFrame {
+	frame := StackFrame{
+		ID:                 f.Id,
+		Name:               f.Name,
+		Line:               f.Line,
+		InstructionPointer: f.InstructionPointerReference,
+		Runtime:            f.PresentationHint == "subtle",
+	}
+	if f.Source != nil {
+		frame.File = f.Source.Path
+		frame.SourceReference = f.Source.SourceReference
+	}
+	return frame
+}
+
+// Variable is a variable, field or element. A positive VariablesReference
+// means it has children, which 'inspect' expands.
+type Variable struct {
+	Name               string `json:"name"`
+	Type               string `json:"type,omitempty"`
+	Value              string `json:"value"`
+	VariablesReference int    `json:"variablesReference,omitempty"`
+	NamedVariables     int    `json:"namedVariables,omitempty"`
+	IndexedVariables   int    `json:"indexedVariables,omitempty"`
+	MemoryReference    string `json:"memoryReference,omitempty"` // for 'read-memory' and 'write-memory'
+}
+
+// newVariable converts a DAP variable.
+func newVariable(v dap.Variable) Variable {
+	return Variable{
+		Name:               v.Name,
+		Type:               v.Type,
+		Value:              v.Value,
+		VariablesReference: v.VariablesReference,
+		NamedVariables:     v.NamedVariables,
+		IndexedVariables:   v.IndexedVariables,
+		MemoryReference:    v.MemoryReference,
+	}
+}
+
+// newVariables converts a list of DAP variables.
+func newVariables(vs []dap.Variable) []Variable {
+	out := make([]Variable, len(vs))
+	for i, v := range vs {
+		out[i] = newVariable(v)
+	}
+	return out
+}
+
+// Scope is a scope of a stack frame, such as locals or arguments.
+type Scope struct {
+	Name               string     `json:"name"`
+	VariablesReference int        `json:"variablesReference,omitempty"`
+	Variables          []Variable `json:"variables,omitempty"`
+	Error              string     `json:"error,omitempty"` // set if the variables could not be retrieved
+}
+
+// ContextResult is the state of a stopped thread: its location, stack
+// trace, and the variables of the selected frame.
+type ContextResult struct {
+	ThreadID    int          `json:"threadId"`
+	FrameID     int          `json:"frameId"` // frame whose scopes are shown
+	Location    *StackFrame  `json:"location,omitempty"`
+	StackTrace  []StackFrame `json:"stackTrace"`
+	Scopes      []Scope      `json:"scopes,omitempty"`
+	ScopesError string       `json:"scopesError,omitempty"` // set if the scopes could not be retrieved
+}
+
+// StackResult is the stack trace of a thread, served by the stack resource.
+type StackResult struct {
+	ThreadID   int          `json:"threadId"`
+	StackTrace []StackFrame `json:"stackTrace"`
+}
+
+// ExceptionResult describes the exception a thread stopped on.
+type ExceptionResult struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description,omitempty"`
+	BreakMode   string            `json:"breakMode,omitempty"`
+	Details     []ExceptionDetail `json:"details,omitempty"` // the exception, then its inner exceptions in depth-first order
+	Error       string            `json:"error,omitempty"`   // set if the exception info could not be retrieved
+}
+
+// ExceptionDetail describes one exception in an exception chain. Depth 0 is
+// the exception itself, 1 its inner exceptions, and so on.
+type ExceptionDetail struct {
+	Depth        int    `json:"depth"`
+	TypeName     string `json:"typeName,omitempty"`
+	Message      string `json:"message,omitempty"`
+	EvaluateName string `json:"evaluateName,omitempty"`
+	StackTrace   string `json:"stackTrace,omitempty"`
+}
+
+// Stop statuses reported in StopResult.Status.
+const (
+	statusStopped    = "stopped"
+	statusRunning    = "running"
+	statusTerminated = "terminated"
+	statusStarted    = "started" // 'debug' returned without waiting for a stop
+)
+
+// StopResult is the outcome of a tool that lets the program run: 'debug',
+// 'continue', 'step', 'pause' and 'wait'.
+type StopResult struct {
+	Status       string           `json:"status"` // "stopped", "running", "terminated" or "started"
+	Reason       string           `json:"reason,omitempty"`
+	ThreadID     int              `json:"threadId,omitempty"`
+	Location     *StackFrame      `json:"location,omitempty"`
+	Exception    *ExceptionResult `json:"exception,omitempty"`
+	UnreadOutput int              `json:"unreadOutput,omitempty"` // output lines waiting for 'output'
+	Context      *ContextResult   `json:"context,omitempty"`      // set when fullContext was requested
+	Source       *SourceResult    `json:"source,omitempty"`       // set when sourceLines was requested
+	Instructions []Instruction    `json:"instructions,omitempty"` // the next instructions, after stepping by instruction
+}
+
+// BreakpointResult describes a breakpoint set by 'breakpoint'.
+type BreakpointResult struct {
+	ID       int    `json:"id,omitempty"`
+	Verified bool   `json:"verified"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Function string `json:"function,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// newBreakpointResult converts a breakpoint reported by the adapter.
+func newBreakpointResult(bp dap.Breakpoint) *BreakpointResult {
+	out := &BreakpointResult{ID: bp.Id, Verified: bp.Verified, Line: bp.Line, Message: bp.Message}
+	if bp.Source != nil {
+		out.File = bp.Source.Path
+	}
+	return out
+}
+
+// BreakpointsResult lists a session's breakpoints, served by the
+// breakpoints resource.
+type BreakpointsResult struct {
+	Breakpoints  []BreakpointSpec        `json:"breakpoints"` // file+line and function breakpoints
+	Instructions []InstructionBreakpoint `json:"instructions"`
+	Watches      []Watch                 `json:"watches"`
+	Exceptions   []ExceptionFilter       `json:"exceptions"` // enabled exception filters
+}
+
+// MessageResult is the result of tools that only report success, such as
+// 'stop' and 'restart'.
+type MessageResult struct {
+	Message string `json:"message"`
+}
+
+// messageResult returns msg as both the text and the structured result.
+func messageResult(msg string) (*mcp.CallToolResult, *MessageResult, error) {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: msg}},
+	}, &MessageResult{Message: msg}, nil
+}
+
+// EvaluateResult is the value of an evaluated expression.
+type EvaluateResult struct {
+	Result             string `json:"result"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference,omitempty"`
+	NamedVariables     int    `json:"namedVariables,omitempty"`
+	IndexedVariables   int    `json:"indexedVariables,omitempty"`
+	MemoryReference    string `json:"memoryReference,omitempty"`
+}
+
+// SetVariableResult is a variable's value after 'set-variable'.
+type SetVariableResult struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference,omitempty"`
+}
+
+// Thread is a thread of the debugged program.
+type Thread struct {
+	ID     int          `json:"id"`
+	Name   string       `json:"name"`
+	Frames []StackFrame `json:"frames,omitempty"` // the top of the thread's stack, current frame first
+}
+
+// ThreadGroup is a set of threads with identical stacks.
+type ThreadGroup struct {
+	Count     int          `json:"count"`
+	ThreadIDs []int        `json:"threadIds"`
+	Frames    []StackFrame `json:"frames"` // the shared stack, with the frame IDs of the first thread
+}
+
+// Module is a module or shared library loaded by the debugged program.
+type Module struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"`
+}
+
+// InfoResult is the result of 'info'. Only the list for Type is set.
+type InfoResult struct {
+	Type      string        `json:"type"` // "threads", "sources", "modules" or "registers"
+	Threads   []Thread      `json:"threads,omitempty"`
+	Groups    []ThreadGroup `json:"groups,omitempty"`    // set instead of Threads with 'group'
+	Total     int           `json:"total,omitempty"`     // threads matching the filter, across all pages
+	NextStart int           `json:"nextStart,omitempty"` // 'start' to pass for the next page of threads or groups
+	Sources   []string      `json:"sources,omitempty"`
+	Modules   []Module      `json:"modules,omitempty"`
+	Registers []Variable    `json:"registers,omitempty"`
+}
+
+// BlockedThread is a thread classified by 'analyze-blocking'.
+type BlockedThread struct {
+	ID       int         `json:"id"`
+	Name     string      `json:"name"`
+	State    string      `json:"state"`              // what it is blocked on, e.g. "chan receive" or "mutex"; or "running", "parked" or "runtime"
+	Object   string      `json:"object,omitempty"`   // address of the channel, lock, etc. it waits on
+	OwnerLWP int         `json:"ownerLwp,omitempty"` // LWP holding the pthread mutex it waits on
+	Location *StackFrame `json:"location,omitempty"` // where it blocked: the first caller of the blocking call outside the Go runtime
+}
+
+// WaitRelation lists the threads waiting on the same object.
+type WaitRelation struct {
+	State    string `json:"state"`
+	Object   string `json:"object"`
+	Waiters  []int  `json:"waiters"`
+	Owner    int    `json:"owner,omitempty"`    // thread holding the object, when known
+	OwnerLWP int    `json:"ownerLwp,omitempty"` // LWP holding the object, when known
+	Note     string `json:"note"`               // what can be inferred about who the waiters wait for
+}
+
+// BlockingResult is the result of 'analyze-blocking'.
+type BlockingResult struct {
+	Threads   []BlockedThread `json:"threads"`
+	Relations []WaitRelation  `json:"relations,omitempty"`
+	Deadlock  bool            `json:"deadlock"` // every thread is blocked on something that cannot end by itself
+}
+
+// OutputLine is one line of program or debugger output.
+type OutputLine struct {
+	Category string `json:"category"`
+	Text     string `json:"text"`
+}
+
+// OutputResult is the output read by 'output'.
+type OutputResult struct {
+	Lines   []OutputLine `json:"lines"`
+	Dropped int          `json:"dropped,omitempty"` // unread lines discarded because the buffer was full
+}
+
+// InspectedVariable is a child in an 'inspect' tree. Depth 1 is a child of
+// the inspected variable, 2 a grandchild, and so on; each entry follows its
+// parent.
+type InspectedVariable struct {
+	Variable
+	Depth int    `json:"depth"`
+	Error string `json:"error,omitempty"` // set if this variable's children could not be retrieved
+}
+
+// MoreElements notes that a collection has elements beyond those shown.
+type MoreElements struct {
+	VariablesReference int `json:"variablesReference"`
+	Remaining          int `json:"remaining"`
+	NextStart          int `json:"nextStart"` // 'start' to pass to 'inspect' for the next page
+}
+
+// InspectResult is the variable tree expanded by 'inspect'.
+type InspectResult struct {
+	Variable  *Variable           `json:"variable,omitempty"` // set when inspected by path
+	Reference int                 `json:"variablesReference"`
+	Children  []InspectedVariable `json:"children"`
+	More      []MoreElements      `json:"more,omitempty"`
+}
+
+// Instruction is a disassembled machine instruction.
+type Instruction struct {
+	Address     string `json:"address"`
+	Instruction string `json:"instruction"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+}
+
+// DisassembleResult is the result of 'disassemble'.
+type DisassembleResult struct {
+	Instructions []Instruction `json:"instructions"`
+}
+
+// MemoryResult is the result of 'read-memory'.
+type MemoryResult struct {
+	Address         string   `json:"address"`                   // address of the first byte read
+	Data            string   `json:"data"`                      // the bytes read, in hex
+	UnreadableBytes int      `json:"unreadableBytes,omitempty"` // bytes after Data that could not be read
+	Format          string   `json:"format,omitempty"`          // integer type of Values
+	Values          []string `json:"values,omitempty"`          // the bytes as integers of Format, in decimal; strings keep 64-bit values exact
+}
+
+// WriteMemoryResult is the result of 'write-memory'.
+type WriteMemoryResult struct {
+	BytesWritten int `json:"bytesWritten"`
+}
+
+// SourceLine is a line of a source listing.
+type SourceLine struct {
+	Line       int    `json:"line"`
+	Text       string `json:"text"`
+	Current    bool   `json:"current,omitempty"`    // the frame's current line
+	Breakpoint bool   `json:"breakpoint,omitempty"` // a breakpoint is set on the line
+}
+
+// SourceResult is a source listing around a location. When no source is
+// available, Instructions holds the disassembly around InstructionPointer
+// instead of Lines.
+type SourceResult struct {
+	File               string        `json:"file,omitempty"`
+	SourceReference    int           `json:"sourceReference,omitempty"`
+	Line               int           `json:"line,omitempty"`
+	Lines              []SourceLine  `json:"lines,omitempty"`
+	InstructionPointer string        `json:"instructionPointer,omitempty"`
+	Instructions       []Instruction `json:"instructions,omitempty"`
+}
+
+// Watch is a data breakpoint set by 'watch'.
+type Watch struct {
+	Name        string `json:"name"`
+	AccessType  string `json:"accessType"`
+	Description string `json:"description,omitempty"`
+	DataID      string `json:"dataId"`
+}
+
+// InstructionBreakpoint is a breakpoint set by 'instruction-breakpoint'.
+type InstructionBreakpoint struct {
+	Address      string `json:"address"`
+	Offset       int    `json:"offset,omitempty"`
+	Condition    string `json:"condition,omitempty"`
+	HitCondition string `json:"hitCondition,omitempty"`
+}
+
+// InstructionBreakpointResult lists the instruction breakpoints after
+// 'instruction-breakpoint'.
+type InstructionBreakpointResult struct {
+	Breakpoints []InstructionBreakpoint `json:"breakpoints"`
+}
+
+// WatchResult lists the data breakpoints after 'watch'.
+type WatchResult struct {
+	Watches []Watch `json:"watches"`
+}
+
+// ExceptionFilter is one of the debug adapter's exception filters.
+type ExceptionFilter struct {
+	Filter      string `json:"filter"`
+	Label       string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
+	Enabled     bool   `json:"enabled"`
+	Condition   string `json:"condition,omitempty"`
+}
+
+// ExceptionFiltersResult lists the exception filters after
+// 'exception-breakpoints'.
+type ExceptionFiltersResult struct {
+	Filters []ExceptionFilter `json:"filters"`
+}
+
+// SessionSummary describes a debug session listed by 'sessions'.
+type SessionSummary struct {
+	Name     string `json:"name"`
+	Current  bool   `json:"current"` // used by tools called without a 'session' parameter
+	Mode     string `json:"mode,omitempty"`
+	Target   string `json:"target,omitempty"`
+	Debugger string `json:"debugger,omitempty"`
+	State    string `json:"state"` // "stopped", "running", "terminated", "busy" or "not started"
+}
+
+// SessionsResult is the result of 'sessions'.
+type SessionsResult struct {
+	Sessions []SessionSummary `json:"sessions"`
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSessionToolOutputSchemas(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	m := registerTools(server, io.Discard, defaultConfig())
+
+	// Enable every capability so that every session tool is registered.
+	var caps dap.Capabilities
+	cv := reflect.ValueOf(&caps).Elem()
+	for i := range cv.NumField() {
+		if f := cv.Field(i); f.Kind() == reflect.Bool {
+			f.SetBool(true)
+		}
+	}
+	caps.ExceptionBreakpointFilters = []dap.ExceptionBreakpointsFilter{{Filter: "panic"}}
+	m.registerSessionTools(caps)
+
+	ctx := context.Background()
+	ct, st := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	res, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]string{"debug", "sessions"}, sessionToolNames(caps)...)
+	if len(res.Tools) != len(want) {
+		t.Errorf("got %d tools, want %d", len(res.Tools), len(want))
+	}
+	for _, tool := range res.Tools {
+		if tool.OutputSchema == nil {
+			t.Errorf("tool %s has no output schema", tool.Name)
+		}
+	}
+}
+
+func TestFormatContext(t *testing.T) {
+	frames := []StackFrame{
+		{ID: 1000, Name: "main.main", File: "/app/main.go", Line: 7},
+		{ID: 1001, Name: "runtime.main", File: "/go/src/runtime/proc.go", Line: 283, Runtime: true},
+	}
+	c := &ContextResult{
+		ThreadID:   1,
+		FrameID:    1000,
+		Location:   &frames[0],
+		StackTrace: frames,
+		Scopes: []Scope{
+			{Name: "Locals", VariablesReference: 1, Variables: []Variable{{Name: "x", Type: "int", Value: "10"}}},
+			{Name: "Globals", VariablesReference: 2, Error: "unable to retrieve variables"},
+		},
+	}
+	want := `## Current Location
+Function: main.main
+File: /app/main.go:7
+
+## Stack Trace
+#0 (Frame ID: 1000) main.main at /app/main.go:7
+#1 (Frame ID: 1001) runtime.main at /go/src/runtime/proc.go:283 (runtime)
+
+## Variables
+### Locals [ref 1]
+  x (int) = 10
+### Globals [ref 2]
+  (unable to retrieve variables)
+`
+	if got := formatContext(c); got != want {
+		t.Errorf("formatContext =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStopToolResult(t *testing.T) {
+	text := func(r *mcp.CallToolResult) string {
+		return r.Content[0].(*mcp.TextContent).Text
+	}
+
+	stop := &StopResult{
+		Status:       statusStopped,
+		Reason:       "breakpoint",
+		ThreadID:     1,
+		Location:     &StackFrame{ID: 1000, Name: "main.main", File: "/app/main.go", Line: 7},
+		UnreadOutput: 2,
+	}
+	want := `Stopped: breakpoint
+Function: main.main
+File: /app/main.go:7
+Program output: 2 new lines (call 'output' to read).
+Call 'context' to inspect stack trace and variables.`
+	if got := text(stopToolResult(stop)); got != want {
+		t.Errorf("stop summary =\n%s\nwant:\n%s", got, want)
+	}
+
+	stop.Exception = &ExceptionResult{ID: "panic"}
+	stop.Context = &ContextResult{StackTrace: []StackFrame{*stop.Location}}
+	if got := text(stopToolResult(stop)); !strings.HasPrefix(got, "## Exception\nID: panic\n\n## Stack Trace\n") {
+		t.Errorf("full context stop = %q, want the exception followed by the context", got)
+	}
+
+	got := text(stopToolResult(&StopResult{Status: statusTerminated}))
+	if got != "Program terminated" {
+		t.Errorf("terminated stop = %q, want %q", got, "Program terminated")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// defaultRunTimeout is how long 'continue', 'step', 'pause' and 'wait' wait
+// for the program to stop before reporting that it is still running.
+const defaultRunTimeout = 30 * time.Second
+
+// errRunning is returned by tools that need a stopped program while it runs.
+var errRunning = fmt.Errorf("program is running; call 'wait' to wait for it to stop or 'pause' to interrupt it")
+
+// errNoReverse is returned for reverse execution when the adapter does not
+// support it.
+var errNoReverse = fmt.Errorf("reverse execution is not supported by this debug adapter; use Delve with backend 'rr', or GDB after enabling process recording with the 'record' command")
+
+// runState tracks a resumed program whose stop has not yet been reported.
+//
+// It subscribes to the DAP client's events and finishes at the next
+// StoppedEvent or TerminatedEvent. Tool handlers wait on done without
+// holding ds.mu, so 'pause' and 'wait' can be called meanwhile.
+type runState struct {
+	done       chan struct{}     // closed once the program stops, terminates, or the run fails
+	once       sync.Once         // guards finishing the run
+	stopped    *dap.StoppedEvent // set if the program stopped
+	terminated bool              // set if the program terminated
+	err        error             // set if the resume request failed or the connection broke
+	onStop     func() error      // optional; called once when the stop or failure is reported (e.g. to drop a run-to-cursor breakpoint)
+}
+
+// watchRun returns a runState that finishes at the next stop or
+// termination reported by client, or when the connection closes.
+// Call it before sending the request that lets the program run, so that
+// an immediate stop is not missed.
+func watchRun(client *DAPClient) *runState {
+	rs := &runState{done: make(chan struct{})}
+	unsubscribe := client.Subscribe(func(ev dap.EventMessage) {
+		switch ev := ev.(type) {
+		case *dap.StoppedEvent:
+			rs.finish(func() { rs.stopped = ev })
+		case *dap.TerminatedEvent:
+			rs.finish(func() { rs.terminated = true })
+		}
+	})
+	go func() {
+		select {
+		case <-rs.done:
+		case <-client.Done():
+			rs.finish(func() { rs.err = client.Err() })
+		}
+		unsubscribe()
+	}()
+	return rs
+}
+
+// finish records the run's outcome with set and closes done, unless the
+// run has already finished.
+func (rs *runState) finish(set func()) {
+	rs.once.Do(func() {
+		set()
+		close(rs.done)
+	})
+}
+
+// cancel stops watching for a stop that is no longer of interest.
+func (rs *runState) cancel() {
+	rs.finish(func() { rs.err = fmt.Errorf("run canceled") })
+}
+
+// resume sends a request that lets the program run (continue, next, ...)
+// and records the run as the session's outstanding run. A failed response
+// to the request finishes the run with its error.
+// Callers must hold ds.mu.
+func (ds *debuggerSession) resume(send func() (int, error)) (*runState, error) {
+	rs := watchRun(ds.client)
+	seq, err := send()
+	if err != nil {
+		rs.cancel()
+		return nil, err
+	}
+	go func(client *DAPClient) {
+		if err := readAndValidateResponse(client, seq, "unable to resume execution"); err != nil {
+			rs.finish(func() { rs.err = err })
+		}
+	}(ds.client)
+	ds.running = rs
+	// The adapter discards variable references when the program resumes.
+	ds.indexedCounts = nil
+	return rs, nil
+}
+
+// waitForStop waits until rs finishes, timeout elapses, or ctx is done.
+// It must be called without holding ds.mu. It reports whether the run
+// finished; on cancellation it returns ctx's error.
+func waitForStop(ctx context.Context, rs *runState, timeout time.Duration) (bool, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-rs.done:
+		return true, nil
+	case <-timer.C:
+		return false, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// stopDetail selects what a stop result includes beyond the location.
+type stopDetail struct {
+	fullContext  bool // include the stack trace and variables
+	sourceLines  int  // lines of source to include before and after the location; 0 for none
+	instructions int  // instructions to disassemble from the instruction pointer; 0 for none
+}
+
+// newStopDetail returns the detail of a stop for a tool call's fullContext
+// and sourceLines parameters. Without fullContext, the server's setting
+// decides.
+func (ds *debuggerSession) newStopDetail(fullContext *bool, sourceLines FlexInt) stopDetail {
+	full := ds.config.FullContext
+	if fullContext != nil {
+		full = *fullContext
+	}
+	return stopDetail{fullContext: full, sourceLines: sourceLines.Int()}
+}
+
+// awaitRun waits for rs without holding ds.mu and returns the stop summary
+// (or full context), a termination notice, or a "still running" notice if
+// timeout elapses first. The program keeps running on timeout or
+// cancellation; a later 'wait' or 'pause' picks up the stop.
+func (ds *debuggerSession) awaitRun(ctx context.Context, rs *runState, timeout time.Duration, detail stopDetail) (*mcp.CallToolResult, *StopResult, error) {
+	finished, err := waitForStop(ctx, rs, timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w (the program is still running; call 'wait' or 'pause')", err)
+	}
+	if !finished {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf(
+				"Program still running after %s. Call 'wait' to keep waiting for it to stop, or 'pause' to interrupt it.", timeout)}},
+		}, &StopResult{Status: statusRunning}, nil
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	stop, err := ds.finishRun(rs, detail)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stopToolResult(stop), stop, nil
+}
+
+// finishRun reports the outcome of a finished run. If rs is still the
+// session's outstanding run, it is cleared and its onStop hook runs.
+// Callers must hold ds.mu.
+func (ds *debuggerSession) finishRun(rs *runState, detail stopDetail) (*StopResult, error) {
+	if ds.client == nil {
+		return nil, fmt.Errorf("debug session ended while the program was running")
+	}
+	if ds.running == rs {
+		ds.running = nil
+		// A failed resume leaves the program where it was, so undo the
+		// run's setup then too.
+		if rs.onStop != nil && !rs.terminated {
+			if err := rs.onStop(); err != nil {
+				log.Printf("finishRun: onStop: %v", err)
+			}
+		}
+	}
+	if rs.err != nil {
+		return nil, rs.err
+	}
+	if rs.terminated {
+		ds.terminated = true
+		return &StopResult{Status: statusTerminated, UnreadOutput: ds.output.unread()}, nil
+	}
+
+	threadID := rs.stopped.Body.ThreadId
+	if threadID != 0 {
+		ds.stoppedThreadID = threadID
+	} else {
+		threadID = ds.defaultThreadID()
+	}
+	stop := &StopResult{Status: statusStopped, Reason: rs.stopped.Body.Reason, ThreadID: threadID}
+	if isExceptionStop(stop.Reason) && ds.capabilities.SupportsExceptionInfoRequest {
+		stop.Exception = ds.exceptionInfo(threadID)
+	}
+	c, err := ds.getFullContext(threadID, 0, ds.config.MaxFrames)
+	if err != nil {
+		return nil, err
+	}
+	stop.Location = c.Location
+	stop.UnreadOutput = ds.output.unread()
+	if detail.fullContext {
+		stop.Context = c
+	}
+	if detail.sourceLines > 0 && c.Location != nil {
+		// A missing source file should not hide the stop itself.
+		if src, err := ds.sourceListing(*c.Location, detail.sourceLines); err != nil {
+			log.Printf("finishRun: source: %v", err)
+		} else {
+			stop.Source = src
+		}
+	}
+	if detail.instructions > 0 && c.Location != nil && c.Location.InstructionPointer != "" && ds.capabilities.SupportsDisassembleRequest {
+		if insts, err := ds.disassemble(c.Location.InstructionPointer, 0, detail.instructions); err != nil {
+			log.Printf("finishRun: disassemble: %v", err)
+		} else {
+			stop.Instructions = insts
+		}
+	}
+	return stop, nil
+}
+
+// runTimeout converts a timeout parameter in seconds to a duration,
+// applying the default when it is unset.
+func runTimeout(seconds FlexInt) time.Duration {
+	if seconds.Int() <= 0 {
+		return defaultRunTimeout
+	}
+	return time.Duration(seconds.Int()) * time.Second
+}
+
+// WaitParams defines the parameters for waiting for a running program.
+type WaitParams struct {
+	SessionParam
+	Timeout     FlexInt `json:"timeout,omitempty" mcp:"seconds to wait for the program to stop (default: 30)"`
+	FullContext *bool   `json:"fullContext,omitempty" mcp:"if true, return full context (stack trace and variables) when stopped; if false, return a compact stop summary (default: compact, unless the server is configured for full context) — leave unset unless you need variables immediately"`
+	SourceLines FlexInt `json:"sourceLines,omitempty" mcp:"if set, include this many lines of source before and after the stop location"`
+}
+
+// wait waits for a running program to stop.
+func (ds *debuggerSession) wait(ctx context.Context, _ *mcp.CallToolRequest, params WaitParams) (*mcp.CallToolResult, *StopResult, error) {
+	ds.mu.Lock()
+	if ds.client == nil {
+		ds.mu.Unlock()
+		return nil, nil, fmt.Errorf("debugger not started")
+	}
+	rs := ds.running
+	ds.mu.Unlock()
+	if rs == nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: "Program is not running. Use 'context' to inspect the current stop location."}},
+		}, &StopResult{Status: statusStopped}, nil
+	}
+	return ds.awaitRun(ctx, rs, runTimeout(params.Timeout), ds.newStopDetail(params.FullContext, params.SourceLines))
+}
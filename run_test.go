@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunTimeout(t *testing.T) {
+	if got := runTimeout(0); got != defaultRunTimeout {
+		t.Errorf("runTimeout(0) = %s, want %s", got, defaultRunTimeout)
+	}
+	if got := runTimeout(-1); got != defaultRunTimeout {
+		t.Errorf("runTimeout(-1) = %s, want %s", got, defaultRunTimeout)
+	}
+	if got := runTimeout(5); got != 5*time.Second {
+		t.Errorf("runTimeout(5) = %s, want 5s", got)
+	}
+}
+
+func TestWaitForStop(t *testing.T) {
+	t.Run("done", func(t *testing.T) {
+		rs := &runState{done: make(chan struct{})}
+		close(rs.done)
+		finished, err := waitForStop(context.Background(), rs, time.Minute)
+		if !finished || err != nil {
+			t.Errorf("waitForStop = %v, %v; want true, nil", finished, err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		rs := &runState{done: make(chan struct{})}
+		finished, err := waitForStop(context.Background(), rs, time.Millisecond)
+		if finished || err != nil {
+			t.Errorf("waitForStop = %v, %v; want false, nil", finished, err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		rs := &runState{done: make(chan struct{})}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		finished, err := waitForStop(ctx, rs, time.Minute)
+		if finished || !errors.Is(err, context.Canceled) {
+			t.Errorf("waitForStop = %v, %v; want false, context.Canceled", finished, err)
+		}
+	})
+}
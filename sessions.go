@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// defaultSessionName names the session started by 'debug' when no
+// 'session' parameter is given.
+const defaultSessionName = "default"
+
+// SessionParam is embedded in the parameters of every session tool to
+// select which debug session the call applies to.
+type SessionParam struct {
+	Session string `json:"session,omitempty" mcp:"debug session to use (default: the most recently started session); see 'sessions'"`
+}
+
+// sessionName returns the requested session name, or "" for the default.
+func (p SessionParam) sessionName() string {
+	return p.Session
+}
+
+// sessionParams is implemented by tool parameters that embed SessionParam.
+type sessionParams interface {
+	sessionName() string
+}
+
+// sessionManager holds the named debug sessions of one MCP server and routes
+// tool calls to them. Session tools are registered while at least one
+// session is active.
+//
+// Lock order: a session's mu may be held while taking the manager's mu, never
+// the reverse.
+type sessionManager struct {
+	server        *mcp.Server
+	logWriter     io.Writer              // writer for adapter stderr, shared by all sessions
+	config        *config                // server settings, shared by all sessions
+	subscriptions *resourceSubscriptions // resource subscriptions to notify; nil if not enabled
+
+	mu         sync.Mutex
+	sessions   map[string]*debuggerSession           // every session created by 'debug' and not yet stopped
+	active     map[*debuggerSession]dap.Capabilities // sessions whose adapter is initialized
+	order      []string                              // names of active sessions, most recently started last
+	registered []string                              // session tools currently registered
+}
+
+// newSessionManager returns a manager with no sessions.
+func newSessionManager(server *mcp.Server, logWriter io.Writer, cfg *config) *sessionManager {
+	return &sessionManager{
+		server:    server,
+		logWriter: logWriter,
+		config:    cfg,
+		sessions:  make(map[string]*debuggerSession),
+		active:    make(map[*debuggerSession]dap.Capabilities),
+	}
+}
+
+// lookup returns the named session, or the most recently started active
+// session if name is empty.
+func (m *sessionManager) lookup(name string) (*debuggerSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if name == "" {
+		if len(m.order) == 0 {
+			return nil, fmt.Errorf("debugger not started")
+		}
+		name = m.order[len(m.order)-1]
+	}
+	ds, ok := m.sessions[name]
+	if !ok {
+		return nil, fmt.Errorf("no debug session named %q; call 'sessions' to list them", name)
+	}
+	return ds, nil
+}
+
+// withSession adapts a session method into a tool handler that runs it on
+// the session selected by the call's 'session' parameter. The method's
+// structured result type determines the tool's output schema.
+func withSession[P sessionParams, Out any](m *sessionManager, h func(*debuggerSession, context.Context, *mcp.CallToolRequest, P) (*mcp.CallToolResult, Out, error)) mcp.ToolHandlerFor[P, Out] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, params P) (*mcp.CallToolResult, Out, error) {
+		ds, err := m.lookup(params.sessionName())
+		if err != nil {
+			var zero Out
+			return nil, zero, err
+		}
+		return h(ds, ctx, req, params)
+	}
+}
+
+// debug starts a session named by params.Session, replacing any session
+// of the same name. A session that fails to start is discarded.
+func (m *sessionManager) debug(ctx context.Context, req *mcp.CallToolRequest, params DebugParams) (*mcp.CallToolResult, *StopResult, error) {
+	name := params.Session
+	if name == "" {
+		name = defaultSessionName
+	}
+	m.mu.Lock()
+	ds, ok := m.sessions[name]
+	if !ok {
+		ds = &debuggerSession{
+			name:        name,
+			manager:     m,
+			logWriter:   m.logWriter,
+			config:      m.config,
+			lastFrameID: -1,
+			output:      newOutputBuffer(defaultOutputLines),
+		}
+		m.sessions[name] = ds
+	}
+	m.mu.Unlock()
+
+	result, out, err := ds.debug(ctx, req, params)
+	if err != nil {
+		ds.mu.Lock()
+		ds.cleanup()
+		ds.mu.Unlock()
+		m.remove(ds)
+		return nil, nil, err
+	}
+	return result, out, nil
+}
+
+// stop ends the selected session and forgets it.
+func (m *sessionManager) stop(ctx context.Context, req *mcp.CallToolRequest, params StopParams) (*mcp.CallToolResult, *MessageResult, error) {
+	ds, err := m.lookup(params.sessionName())
+	if err != nil {
+		return nil, nil, err
+	}
+	result, out, err := ds.stop(ctx, req, params)
+	m.remove(ds)
+	return result, out, err
+}
+
+// remove forgets ds, unless its name has since been reused.
+func (m *sessionManager) remove(ds *debuggerSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions[ds.name] == ds {
+		delete(m.sessions, ds.name)
+	}
+}
+
+// activate records that ds has started and registers the session tools its
+// adapter supports. Callers must hold ds.mu.
+func (m *sessionManager) activate(ds *debuggerSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active[ds] = ds.capabilities
+	m.order = append(slices.DeleteFunc(m.order, func(n string) bool { return n == ds.name }), ds.name)
+	m.refreshTools()
+}
+
+// deactivate records that ds has ended, and unregisters the session tools
+// once no session is active. Callers must hold ds.mu.
+func (m *sessionManager) deactivate(ds *debuggerSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.active[ds]; !ok {
+		return
+	}
+	delete(m.active, ds)
+	m.order = slices.DeleteFunc(m.order, func(n string) bool { return n == ds.name })
+	m.refreshTools()
+}
+
+// refreshTools re-registers the session tools for the union of the active
+// sessions' capabilities. Callers must hold m.mu.
+func (m *sessionManager) refreshTools() {
+	if len(m.registered) > 0 {
+		m.server.RemoveTools(m.registered...)
+		m.registered = nil
+	}
+	if len(m.active) == 0 {
+		return
+	}
+	var caps dap.Capabilities
+	for _, c := range m.active {
+		caps = mergeCapabilities(caps, c)
+	}
+	m.registerSessionTools(caps)
+	m.registered = sessionToolNames(caps)
+}
+
+// mergeCapabilities returns a with every boolean capability that b supports
+// also enabled, and b's exception filters added.
+func mergeCapabilities(a, b dap.Capabilities) dap.Capabilities {
+	av := reflect.ValueOf(&a).Elem()
+	bv := reflect.ValueOf(b)
+	for i := range av.NumField() {
+		if f := av.Field(i); f.Kind() == reflect.Bool && bv.Field(i).Bool() {
+			f.SetBool(true)
+		}
+	}
+	a.ExceptionBreakpointFilters = slices.Clone(a.ExceptionBreakpointFilters)
+	for _, f := range b.ExceptionBreakpointFilters {
+		if !slices.ContainsFunc(a.ExceptionBreakpointFilters, func(g dap.ExceptionBreakpointsFilter) bool { return g.Filter == f.Filter }) {
+			a.ExceptionBreakpointFilters = append(a.ExceptionBreakpointFilters, f)
+		}
+	}
+	return a
+}
+
+// updateCapabilities re-registers the session tools after an active
+// session's capabilities changed. Callers must hold ds.mu.
+func (m *sessionManager) updateCapabilities(ds *debuggerSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.active[ds]; !ok {
+		return
+	}
+	m.active[ds] = ds.capabilities
+	m.refreshTools()
+}
+
+// capabilityUpdate collects the capabilities an adapter announces with
+// 'capabilities' events. The events arrive on the DAP client's read loop,
+// which must not take ds.mu, so they are merged into ds.capabilities later.
+type capabilityUpdate struct {
+	mu      sync.Mutex
+	caps    dap.Capabilities
+	pending bool
+}
+
+// add records newly announced capabilities.
+func (u *capabilityUpdate) add(caps dap.Capabilities) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.caps = mergeCapabilities(u.caps, caps)
+	u.pending = true
+}
+
+// take returns and clears the capabilities recorded since the last call.
+func (u *capabilityUpdate) take() (dap.Capabilities, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	caps, ok := u.caps, u.pending
+	u.caps, u.pending = dap.Capabilities{}, false
+	return caps, ok
+}
+
+// applyCapabilityUpdate merges capabilities announced by the adapter into
+// the session's, and updates the advertised tools.
+func (ds *debuggerSession) applyCapabilityUpdate() {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	caps, ok := ds.capsUpdate.take()
+	if !ok || ds.client == nil {
+		return
+	}
+	ds.capabilities = mergeCapabilities(ds.capabilities, caps)
+	if ds.manager != nil {
+		ds.manager.updateCapabilities(ds)
+	}
+}
+
+// cleanup ends every session. It is called when the server shuts down.
+func (m *sessionManager) cleanup() {
+	m.mu.Lock()
+	sessions := make([]*debuggerSession, 0, len(m.sessions))
+	for _, ds := range m.sessions {
+		sessions = append(sessions, ds)
+	}
+	m.mu.Unlock()
+	for _, ds := range sessions {
+		ds.mu.Lock()
+		ds.cleanup()
+		ds.mu.Unlock()
+		m.remove(ds)
+	}
+}
+
+// SessionsParams defines the parameters for listing debug sessions.
+type SessionsParams struct{}
+
+// listSessions describes every debug session.
+func (m *sessionManager) listSessions(ctx context.Context, _ *mcp.CallToolRequest, _ SessionsParams) (*mcp.CallToolResult, *SessionsResult, error) {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.sessions))
+	for name := range m.sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sessions := make([]*debuggerSession, len(names))
+	for i, name := range names {
+		sessions[i] = m.sessions[name]
+	}
+	current := ""
+	if len(m.order) > 0 {
+		current = m.order[len(m.order)-1]
+	}
+	m.mu.Unlock()
+
+	out := &SessionsResult{Sessions: make([]SessionSummary, len(sessions))}
+	if len(sessions) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: "No debug sessions. Use 'debug' to start one."}},
+		}, out, nil
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Sessions (%d):\n", len(sessions))
+	for i, ds := range sessions {
+		s := ds.summary()
+		s.Current = ds.name == current
+		out.Sessions[i] = s
+		marker := " "
+		if s.Current {
+			marker = "*"
+		}
+		fmt.Fprintf(&result, "%s %s: %s\n", marker, ds.name, formatSessionSummary(s))
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: result.String()}},
+	}, out, nil
+}
+
+// summary describes the session's mode, target, debugger and state. It does
+// not wait for a tool call in progress on the session, such as a 'debug'
+// waiting for the first breakpoint; such sessions are reported as busy.
+func (ds *debuggerSession) summary() SessionSummary {
+	if !ds.mu.TryLock() {
+		return SessionSummary{Name: ds.name, State: "busy"}
+	}
+	defer ds.mu.Unlock()
+	s := SessionSummary{Name: ds.name, State: "not started"}
+	if ds.client == nil {
+		return s
+	}
+	s.Mode, s.Target, s.Debugger = ds.launchMode, ds.target, ds.debugger
+	switch {
+	case ds.running != nil:
+		s.State = "running"
+	case ds.terminated:
+		s.State = "terminated"
+	default:
+		s.State = "stopped"
+	}
+	return s
+}
+
+// formatSessionSummary renders a session summary for the 'sessions' listing.
+func formatSessionSummary(s SessionSummary) string {
+	switch s.State {
+	case "busy":
+		return "busy (a tool call is in progress)"
+	case "not started":
+		return s.State
+	}
+	return fmt.Sprintf("%s %s [%s] — %s", s.Mode, s.Target, s.Debugger, s.State)
+}
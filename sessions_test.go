@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestMergeCapabilities(t *testing.T) {
+	a := dap.Capabilities{SupportsConfigurationDoneRequest: true}
+	b := dap.Capabilities{SupportsSetVariable: true, SupportsStepBack: true}
+	got := mergeCapabilities(a, b)
+	if !got.SupportsConfigurationDoneRequest || !got.SupportsSetVariable || !got.SupportsStepBack {
+		t.Errorf("mergeCapabilities = %+v, want all three capabilities set", got)
+	}
+	if got.SupportsDisassembleRequest {
+		t.Errorf("mergeCapabilities enabled an unsupported capability: %+v", got)
+	}
+
+	a.ExceptionBreakpointFilters = []dap.ExceptionBreakpointsFilter{{Filter: "panic"}}
+	b.ExceptionBreakpointFilters = []dap.ExceptionBreakpointsFilter{{Filter: "panic"}, {Filter: "throw"}}
+	got = mergeCapabilities(a, b)
+	if len(got.ExceptionBreakpointFilters) != 2 || got.ExceptionBreakpointFilters[1].Filter != "throw" {
+		t.Errorf("expected exception filters [panic throw], got: %v", got.ExceptionBreakpointFilters)
+	}
+	if len(a.ExceptionBreakpointFilters) != 1 {
+		t.Errorf("mergeCapabilities modified its argument: %v", a.ExceptionBreakpointFilters)
+	}
+}
+
+func TestCapabilityUpdate(t *testing.T) {
+	var u capabilityUpdate
+	if _, ok := u.take(); ok {
+		t.Error("take on an empty update reported pending capabilities")
+	}
+	u.add(dap.Capabilities{SupportsStepBack: true})
+	u.add(dap.Capabilities{SupportsSetVariable: true})
+	caps, ok := u.take()
+	if !ok || !caps.SupportsStepBack || !caps.SupportsSetVariable {
+		t.Errorf("take = %+v, %v; want both added capabilities", caps, ok)
+	}
+	if _, ok := u.take(); ok {
+		t.Error("take did not clear the update")
+	}
+}
+
+func TestSessionLookup(t *testing.T) {
+	m := newSessionManager(mcp.NewServer(&mcp.Implementation{Name: "test"}, nil), io.Discard, defaultConfig())
+
+	if _, err := m.lookup(""); err == nil || !strings.Contains(err.Error(), "not started") {
+		t.Errorf("lookup with no sessions: err = %v, want 'not started'", err)
+	}
+
+	a := &debuggerSession{name: "a", manager: m}
+	b := &debuggerSession{name: "b", manager: m}
+	m.sessions["a"], m.sessions["b"] = a, b
+	m.activate(a)
+	m.activate(b)
+
+	if ds, err := m.lookup(""); err != nil || ds != b {
+		t.Errorf("lookup(\"\") = %v, %v; want session b", ds, err)
+	}
+	if ds, err := m.lookup("a"); err != nil || ds != a {
+		t.Errorf("lookup(\"a\") = %v, %v; want session a", ds, err)
+	}
+	if _, err := m.lookup("c"); err == nil {
+		t.Error("lookup(\"c\") succeeded, want error")
+	}
+
+	m.deactivate(b)
+	if ds, err := m.lookup(""); err != nil || ds != a {
+		t.Errorf("lookup(\"\") after deactivating b = %v, %v; want session a", ds, err)
+	}
+	m.deactivate(a)
+	if len(m.registered) != 0 {
+		t.Errorf("tools still registered with no active sessions: %v", m.registered)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// defaultSourceLines is the number of lines 'source' shows before and after
+// the current line.
+const defaultSourceLines = 5
+
+// SourceParams defines the parameters for listing source code.
+type SourceParams struct {
+	SessionParam
+	ThreadID FlexInt `json:"threadId,omitempty" mcp:"thread whose stop location to show (default: current thread)"`
+	FrameID  FlexInt `json:"frameId,omitempty" mcp:"stack frame whose location to show (default: top frame)"`
+	File     string  `json:"file,omitempty" mcp:"source file to list instead of a stack frame's location; requires line"`
+	Line     FlexInt `json:"line,omitempty" mcp:"line to list around, with file"`
+	Lines    FlexInt `json:"lines,omitempty" mcp:"lines to show before and after the current line (default: 5)"`
+}
+
+// source lists the source around a stack frame's location, or around a
+// given file and line.
+func (ds *debuggerSession) source(ctx context.Context, _ *mcp.CallToolRequest, params SourceParams) (*mcp.CallToolResult, *SourceResult, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.client == nil {
+		return nil, nil, fmt.Errorf("debugger not started")
+	}
+	radius := params.Lines.Int()
+	if radius <= 0 {
+		radius = defaultSourceLines
+	}
+
+	var frame StackFrame
+	if params.File != "" {
+		if params.Line.Int() <= 0 {
+			return nil, nil, fmt.Errorf("line is required with file")
+		}
+		frame = StackFrame{File: params.File, Line: params.Line.Int()}
+	} else {
+		if ds.running != nil {
+			return nil, nil, errRunning
+		}
+		threadID := params.ThreadID.Int()
+		if threadID == 0 {
+			threadID = ds.defaultThreadID()
+		}
+		frames, err := ds.stackTrace(threadID, defaultMaxFrames)
+		if err != nil {
+			return nil, nil, err
+		}
+		i := 0
+		if id := params.FrameID.Int(); id != 0 {
+			i = slices.IndexFunc(frames, func(f StackFrame) bool { return f.ID == id })
+		}
+		if i < 0 || i >= len(frames) {
+			return nil, nil, fmt.Errorf("frame %d not found in the stack of thread %d", params.FrameID.Int(), threadID)
+		}
+		frame = frames[i]
+	}
+
+	src, err := ds.sourceListing(frame, radius)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: formatSource(src)}},
+	}, src, nil
+}
+
+// sourceListing returns up to radius lines before and after frame's line.
+// The source is read from the local file, or from the debug adapter for
+// frames with a sourceReference; without either, the instructions around
+// the frame's instruction pointer are disassembled instead.
+// Callers must hold ds.mu.
+func (ds *debuggerSession) sourceListing(frame StackFrame, radius int) (*SourceResult, error) {
+	src := &SourceResult{File: frame.File, SourceReference: frame.SourceReference, Line: frame.Line}
+	var content string
+	switch {
+	case frame.File != "" && fileExists(frame.File):
+		data, err := os.ReadFile(frame.File)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read source: %w", err)
+		}
+		content = string(data)
+	case frame.SourceReference > 0:
+		seq, err := ds.client.SourceRequest(frame.SourceReference)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := readTypedResponse[*dap.SourceResponse](ds.client, seq)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get source: %w", err)
+		}
+		content = resp.Body.Content
+	case frame.InstructionPointer != "" && ds.capabilities.SupportsDisassembleRequest:
+		instructions, err := ds.disassemble(frame.InstructionPointer, -radius, 2*radius+1)
+		if err != nil {
+			return nil, fmt.Errorf("no source available, and %w", err)
+		}
+		src.Instructions = instructions
+		src.InstructionPointer = frame.InstructionPointer
+		return src, nil
+	default:
+		if frame.File != "" {
+			return nil, fmt.Errorf("source file not found: %s", frame.File)
+		}
+		return nil, fmt.Errorf("no source available for %s", frame.Name)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if frame.Line < 1 || frame.Line > len(lines) {
+		return nil, fmt.Errorf("line %d is outside the source (%d lines)", frame.Line, len(lines))
+	}
+	var breakpoints []dap.SourceBreakpoint
+	if frame.File != "" {
+		breakpoints = ds.breakpoints.sourceBreakpoints(frame.File)
+	}
+	first := max(1, frame.Line-radius)
+	last := min(len(lines), frame.Line+radius)
+	for n := first; n <= last; n++ {
+		src.Lines = append(src.Lines, SourceLine{
+			Line:       n,
+			Text:       strings.TrimSuffix(lines[n-1], "\r"),
+			Current:    n == frame.Line,
+			Breakpoint: slices.ContainsFunc(breakpoints, func(bp dap.SourceBreakpoint) bool { return bp.Line == n }),
+		})
+	}
+	return src, nil
+}
+
+// fileExists reports whether path names an existing regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// formatSource renders a source listing. The current line is marked '=>'
+// and lines with a breakpoint '*'.
+func formatSource(src *SourceResult) string {
+	var result strings.Builder
+	if src.Instructions != nil {
+		fmt.Fprintf(&result, "No source available; disassembly around %s:\n", src.InstructionPointer)
+		for _, inst := range src.Instructions {
+			marker := "  "
+			if inst.Address == src.InstructionPointer {
+				marker = "=>"
+			}
+			fmt.Fprintf(&result, "%s %s  %s\n", marker, inst.Address, inst.Instruction)
+		}
+		return result.String()
+	}
+	switch {
+	case src.File != "":
+		fmt.Fprintf(&result, "%s:\n", src.File)
+	case src.SourceReference > 0:
+		fmt.Fprintf(&result, "(source reference %d):\n", src.SourceReference)
+	}
+	width := len(fmt.Sprint(src.Lines[len(src.Lines)-1].Line))
+	for _, l := range src.Lines {
+		marker := "  "
+		if l.Current {
+			marker = "=>"
+		}
+		bp := " "
+		if l.Breakpoint {
+			bp = "*"
+		}
+		fmt.Fprintf(&result, "%s%s %*d  %s\n", marker, bp, width, l.Line, l.Text)
+	}
+	return result.String()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-dap"
+)
+
+func TestSourceListing(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.go")
+	var content strings.Builder
+	for i := 1; i <= 20; i++ {
+		content.WriteString("line " + string(rune('a'+i-1)) + "\n")
+	}
+	if err := os.WriteFile(file, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ds := &debuggerSession{}
+	ds.breakpoints.setSource(file, dap.SourceBreakpoint{Line: 9})
+	ds.breakpoints.setSource(file, dap.SourceBreakpoint{Line: 30})
+
+	src, err := ds.sourceListing(StackFrame{File: file, Line: 10}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SourceLine{
+		{Line: 8, Text: "line h"},
+		{Line: 9, Text: "line i", Breakpoint: true},
+		{Line: 10, Text: "line j", Current: true},
+		{Line: 11, Text: "line k"},
+		{Line: 12, Text: "line l"},
+	}
+	if len(src.Lines) != len(want) {
+		t.Fatalf("got lines %+v, want %+v", src.Lines, want)
+	}
+	for i := range want {
+		if src.Lines[i] != want[i] {
+			t.Errorf("line %d: got %+v, want %+v", i, src.Lines[i], want[i])
+		}
+	}
+
+	text := formatSource(src)
+	for _, line := range []string{"  *  9  line i", "=>  10  line j", "    12  line l"} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("listing does not contain %q:\n%s", line, text)
+		}
+	}
+
+	// The listing is clipped at the start and end of the file.
+	src, err = ds.sourceListing(StackFrame{File: file, Line: 20}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first, last := src.Lines[0].Line, src.Lines[len(src.Lines)-1].Line; first != 17 || last != 20 {
+		t.Errorf("got lines %d-%d, want 17-20", first, last)
+	}
+
+	if _, err := ds.sourceListing(StackFrame{File: file, Line: 21}, 3); err == nil {
+		t.Error("listing past the end of the file succeeded, want error")
+	}
+	if _, err := ds.sourceListing(StackFrame{File: filepath.Join(t.TempDir(), "missing.go"), Line: 1}, 3); err == nil {
+		t.Error("listing a missing file without a source reference succeeded, want error")
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	cwd, _ := os.Getwd()
+	mode := os.Getenv("MODE")
+	fmt.Println("CONFIG_PATH=" + os.Getenv("CONFIG_PATH"))
+	fmt.Println("MODE=" + mode)
+	fmt.Println("cwd=" + cwd)
+}
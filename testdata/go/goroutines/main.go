@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+func worker(id int, jobs <-chan int) {
+	for j := range jobs {
+		fmt.Println(id, j)
+	}
+}
+
+func locker(mu *sync.Mutex, wg *sync.WaitGroup) {
+	defer wg.Done()
+	mu.Lock()
+	defer mu.Unlock()
+}
+
+func main() {
+	jobs := make(chan int)
+	for i := range 10 {
+		go worker(i, jobs)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	mu.Lock()
+	for range 3 {
+		wg.Add(1)
+		go locker(&mu, &wg)
+	}
+	time.Sleep(100 * time.Millisecond) // let the goroutines block
+	fmt.Println("blocked")
+	mu.Unlock()
+	wg.Wait()
+	close(jobs)
+}
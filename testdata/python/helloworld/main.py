@@ -0,0 +1,14 @@
+def add(a, b):
+    result = a + b
+    return result
+
+
+def main():
+    x = 10
+    y = 20
+    total = add(x, y)
+    print(f"Sum: {total}")
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultThreadCount = 100 // threads or groups listed per page by 'info'
+	defaultGroupDepth  = 5   // frames compared when grouping, since blocked goroutines share their top frames
+	maxThreadDepth     = 10  // most stack frames fetched per thread by 'info'
+
+	// stackRequestBatch is the number of stackTrace requests kept in flight
+	// while listing threads, so that thousands of goroutines don't take
+	// thousands of round trips.
+	stackRequestBatch = 64
+)
+
+// listThreads lists the program's threads (goroutines, for Delve) with
+// their current location. Threads can be filtered, paged, and grouped by
+// identical stacks. Callers must hold ds.mu.
+func (ds *debuggerSession) listThreads(params InfoParams) (*mcp.CallToolResult, *InfoResult, error) {
+	var filter *regexp.Regexp
+	if params.Filter != "" {
+		var err error
+		if filter, err = regexp.Compile(params.Filter); err != nil {
+			return nil, nil, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+	depth := params.Depth.Int()
+	if depth <= 0 {
+		depth = 1
+		if params.Group {
+			depth = defaultGroupDepth
+		}
+	}
+	depth = min(depth, maxThreadDepth)
+	start := max(params.Start.Int(), 0)
+	count := params.Count.Int()
+	if count <= 0 {
+		count = defaultThreadCount
+	}
+
+	seq, err := ds.client.ThreadsRequest()
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := readTypedResponse[*dap.ThreadsResponse](ds.client, seq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get threads: %w", err)
+	}
+	threads := make([]Thread, len(resp.Body.Threads))
+	for i, t := range resp.Body.Threads {
+		threads[i] = Thread{ID: t.Id, Name: t.Name}
+	}
+
+	// Filtering and grouping need every thread's stack; a plain listing
+	// only the stacks of the page shown.
+	if filter != nil || params.Group {
+		ds.fetchThreadStacks(threads, depth)
+		if filter != nil {
+			threads = slices.DeleteFunc(threads, func(t Thread) bool { return !t.matches(filter) })
+		}
+	}
+
+	out := &InfoResult{Type: "threads", Total: len(threads)}
+	var result strings.Builder
+	if params.Group {
+		groups := groupThreads(threads)
+		page, next := pageOf(groups, start, count)
+		out.Groups, out.NextStart = page, next
+		fmt.Fprintf(&result, "%d threads in %d groups with identical stacks", len(threads), len(groups))
+		writePageRange(&result, start, len(page), len(groups))
+		for _, g := range page {
+			writeThreadGroup(&result, g)
+		}
+	} else {
+		page, next := pageOf(threads, start, count)
+		if filter == nil {
+			ds.fetchThreadStacks(page, depth)
+		}
+		out.Threads, out.NextStart = page, next
+		fmt.Fprintf(&result, "%d threads", len(threads))
+		writePageRange(&result, start, len(page), len(threads))
+		for _, t := range page {
+			fmt.Fprintf(&result, "  Thread %d: %s\n", t.ID, t.Name)
+			for _, f := range t.Frames {
+				fmt.Fprintf(&result, "      at %s\n", frameLocation(f))
+			}
+		}
+	}
+	if out.NextStart > 0 {
+		fmt.Fprintf(&result, "More available: pass start=%d for the next page.\n", out.NextStart)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: result.String()}},
+	}, out, nil
+}
+
+// fetchThreadStacks fills in the top depth frames of each thread. The
+// requests are pipelined in batches. A thread whose stack cannot be read,
+// for example because it exited, is left without frames.
+func (ds *debuggerSession) fetchThreadStacks(threads []Thread, depth int) {
+	for batch := range slices.Chunk(threads, stackRequestBatch) {
+		seqs := make([]int, len(batch))
+		for i, t := range batch {
+			seq, err := ds.client.StackTraceRequest(t.ID, 0, depth)
+			if err != nil {
+				seq = -1
+			}
+			seqs[i] = seq
+		}
+		for i, seq := range seqs {
+			if seq < 0 {
+				continue
+			}
+			resp, err := readTypedResponse[*dap.StackTraceResponse](ds.client, seq)
+			if err != nil {
+				continue
+			}
+			batch[i].Frames = make([]StackFrame, len(resp.Body.StackFrames))
+			for j, f := range resp.Body.StackFrames {
+				batch[i].Frames[j] = newStackFrame(f)
+			}
+		}
+	}
+}
+
+// matches reports whether the thread's name or any of its fetched frames'
+// function or file:line matches re.
+func (t Thread) matches(re *regexp.Regexp) bool {
+	if re.MatchString(t.Name) {
+		return true
+	}
+	return slices.ContainsFunc(t.Frames, func(f StackFrame) bool {
+		return re.MatchString(f.Name) || (f.File != "" && re.MatchString(fmt.Sprintf("%s:%d", f.File, f.Line)))
+	})
+}
+
+// stackKey identifies a thread's stack by its frames' functions and
+// locations, ignoring frame IDs.
+func (t Thread) stackKey() string {
+	var key strings.Builder
+	for _, f := range t.Frames {
+		fmt.Fprintf(&key, "%s\x00%s\x00%d\x00%s\n", f.Name, f.File, f.Line, f.InstructionPointer)
+	}
+	return key.String()
+}
+
+// groupThreads groups threads with identical stacks, largest group first.
+// Groups of equal size keep the order of their first thread.
+func groupThreads(threads []Thread) []ThreadGroup {
+	var groups []ThreadGroup
+	index := make(map[string]int)
+	for _, t := range threads {
+		key := t.stackKey()
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ThreadGroup{Frames: t.Frames})
+		}
+		groups[i].Count++
+		groups[i].ThreadIDs = append(groups[i].ThreadIDs, t.ID)
+	}
+	slices.SortStableFunc(groups, func(a, b ThreadGroup) int { return b.Count - a.Count })
+	return groups
+}
+
+// pageOf returns count items of s from start, and the start of the next
+// page, or 0 if there is none.
+func pageOf[T any](s []T, start, count int) ([]T, int) {
+	if start >= len(s) {
+		return nil, 0
+	}
+	end := min(start+count, len(s))
+	if end == len(s) {
+		return s[start:end], 0
+	}
+	return s[start:end], end
+}
+
+// writePageRange finishes a listing heading with the range shown, when it
+// is not everything.
+func writePageRange(result *strings.Builder, start, shown, total int) {
+	if shown < total {
+		if shown == 0 {
+			fmt.Fprintf(result, " (none from %d)", start)
+		} else {
+			fmt.Fprintf(result, " (showing %d-%d)", start+1, start+shown)
+		}
+	}
+	result.WriteString(":\n")
+}
+
+// maxGroupThreadIDs is the number of thread IDs listed per group in text
+// output; the structured result has all of them.
+const maxGroupThreadIDs = 20
+
+// writeThreadGroup renders a group of threads with identical stacks.
+func writeThreadGroup(result *strings.Builder, g ThreadGroup) {
+	plural := "s"
+	if g.Count == 1 {
+		plural = ""
+	}
+	fmt.Fprintf(result, "  %d thread%s: %s\n", g.Count, plural, joinIDs(g.ThreadIDs))
+	if len(g.Frames) == 0 {
+		result.WriteString("      (stack unavailable)\n")
+	}
+	for _, f := range g.Frames {
+		fmt.Fprintf(result, "      at %s\n", frameLocation(f))
+	}
+}
+
+// joinIDs lists thread IDs, eliding all but the first few.
+func joinIDs(ids []int) string {
+	shown := make([]string, 0, min(len(ids), maxGroupThreadIDs))
+	for _, id := range ids[:cap(shown)] {
+		shown = append(shown, strconv.Itoa(id))
+	}
+	if len(ids) > maxGroupThreadIDs {
+		shown = append(shown, fmt.Sprintf("... %d more", len(ids)-maxGroupThreadIDs))
+	}
+	return strings.Join(shown, ", ")
+}
+
+// frameLocation renders a frame as "function (file:line)", or with the
+// instruction pointer when the frame has no source.
+func frameLocation(f StackFrame) string {
+	switch {
+	case f.File != "":
+		return fmt.Sprintf("%s (%s:%d)", f.Name, f.File, f.Line)
+	case f.InstructionPointer != "":
+		return fmt.Sprintf("%s (%s)", f.Name, f.InstructionPointer)
+	}
+	return f.Name
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestGroupThreads(t *testing.T) {
+	parked := []StackFrame{{ID: 1, Name: "runtime.gopark"}, {ID: 2, Name: "main.worker", File: "main.go", Line: 10}}
+	threads := []Thread{
+		{ID: 1, Name: "main", Frames: []StackFrame{{ID: 3, Name: "main.main", File: "main.go", Line: 34}}},
+		{ID: 2, Frames: parked},
+		{ID: 3, Frames: []StackFrame{{ID: 4, Name: "runtime.gopark"}, {ID: 5, Name: "main.worker", File: "main.go", Line: 10}}},
+		{ID: 4},
+	}
+	groups := groupThreads(threads)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3: %+v", len(groups), groups)
+	}
+	if groups[0].Count != 2 || !slices.Equal(groups[0].ThreadIDs, []int{2, 3}) || !slices.Equal(groups[0].Frames, parked) {
+		t.Errorf("largest group = %+v, want threads 2 and 3 with the first one's frames", groups[0])
+	}
+	if !slices.Equal(groups[1].ThreadIDs, []int{1}) || !slices.Equal(groups[2].ThreadIDs, []int{4}) {
+		t.Errorf("groups of one = %+v, %+v; want threads 1 then 4", groups[1], groups[2])
+	}
+
+	if !threads[2].matches(regexp.MustCompile(`main\.go:10$`)) || threads[0].matches(regexp.MustCompile("worker")) {
+		t.Error("matches does not check the frames' functions and locations")
+	}
+}
+
+func TestPageOf(t *testing.T) {
+	s := []int{0, 1, 2, 3, 4}
+	tests := []struct {
+		start, count int
+		page         []int
+		next         int
+	}{
+		{0, 2, []int{0, 1}, 2},
+		{2, 3, []int{2, 3, 4}, 0},
+		{4, 10, []int{4}, 0},
+		{5, 2, nil, 0},
+	}
+	for _, tt := range tests {
+		page, next := pageOf(s, tt.start, tt.count)
+		if !slices.Equal(page, tt.page) || next != tt.next {
+			t.Errorf("pageOf(%d, %d) = %v, %d; want %v, %d", tt.start, tt.count, page, next, tt.page, tt.next)
+		}
+	}
+}
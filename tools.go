@@ -6,30 +6,58 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
 
 	"github.com/google/go-dap"
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type debuggerSession struct {
-	mu              sync.Mutex       // serializes DAP requests to prevent concurrent read races
+	mu              sync.Mutex // serializes DAP requests to prevent concurrent read races
+	name            string     // session name, as passed to tools' 'session' parameter
 	cmd             *exec.Cmd
 	client          *DAPClient
-	server          *mcp.Server      // MCP server for dynamic tool registration
-	logWriter       io.Writer        // writer for adapter stderr (log file or io.Discard)
-	backend         DebuggerBackend  // debugger-specific backend (delve, gdb, etc.)
-	capabilities    dap.Capabilities // capabilities reported by DAP server
-	launchMode      string           // "source", "binary", "core", or "attach"
-	programPath     string           // path to program being debugged
-	programArgs     []string         // command line arguments
-	coreFilePath    string           // path to core dump file (core mode only)
-	stoppedThreadID int              // thread ID from last StoppedEvent (for adapters that use non-sequential IDs)
-	lastFrameID     int              // frame ID from last getFullContext; -1 means not set (0 is valid for GDB)
-	protocolLogFile *os.File         // protocol log file (closed on cleanup)
+	manager         *sessionManager    // registers tools while the session is active
+	logWriter       io.Writer          // writer for adapter stderr (log file or io.Discard)
+	config          *config            // server settings
+	backend         DebuggerBackend    // debugger-specific backend (delve, gdb, etc.)
+	debugger        string             // backend name: "delve", "gdb", "lldb", or "debugpy"
+	capabilities    dap.Capabilities   // capabilities reported by DAP server
+	launchMode      string             // "source", "binary", "core", "attach", "remote", "record", or "replay"
+	target          string             // what is being debugged, for 'sessions': program path, pid, or address
+	terminated      bool               // the program has terminated
+	programPath     string             // path to program being debugged
+	programArgs     []string           // command line arguments
+	coreFilePath    string             // path to core dump file (core mode only)
+	stoppedThreadID int                // thread ID from last StoppedEvent (for adapters that use non-sequential IDs)
+	running         *runState          // outstanding continue/step; nil while stopped
+	launchArgs      map[string]any     // arguments of the launch or attach request, resent by 'restart'
+	lastFrameID     int                // frame ID from last getFullContext; -1 means not set (0 is valid for GDB)
+	indexedCounts   map[int]int        // element counts of the references shown since the last resume, by reference
+	breakpoints     breakpointRegistry // every breakpoint set this session; the adapter's sets are replaced from it
+	protocolLogFile *os.File           // protocol log file (closed on cleanup)
+	output          *outputBuffer      // program output from OutputEvents
+	capsUpdate      capabilityUpdate   // capabilities announced by the adapter after initialization
+}
+
+// handleEvent records events the session tracks for its whole lifetime,
+// such as program output. It runs on the DAP client's read loop, so it must
+// not take ds.mu.
+func (ds *debuggerSession) handleEvent(ev dap.EventMessage) {
+	switch e := ev.(type) {
+	case *dap.OutputEvent:
+		ds.output.write(e.Body.Category, e.Body.Output)
+	case *dap.StoppedEvent, *dap.TerminatedEvent:
+		ds.notifyResources()
+	case *dap.CapabilitiesEvent:
+		ds.capsUpdate.add(e.Body.Capabilities)
+		go ds.applyCapabilityUpdate()
+	}
 }
 
 // defaultThreadID returns the thread ID to use when none is specified.
@@ -43,31 +71,41 @@ func (ds *debuggerSession) defaultThreadID() int {
 
 const debugToolDescription = `Start a complete debugging session.
 
-Modes: 'source' (compile & debug), 'binary' (debug executable), 'core' (debug core dump), 'attach' (connect to process).
+Modes: 'source' (compile & debug), 'binary' (debug executable), 'core' (debug core dump), 'attach' (connect to process), 'remote' (connect to an already-running Delve server at 'address', started with 'dlv --headless --accept-multiclient', e.g. in a container; a plain 'dlv dap' server is not supported; 'stop' disconnects and leaves the server running), 'record' (Delve only: record a run of the program at 'path' with rr, then replay it), 'replay' (Delve only: replay the rr trace at 'traceDir').
 
 Debugger selection (via 'debugger' parameter):
 - 'delve' (default): For Go programs only. Requires dlv to be installed.
 - 'gdb': For C/C++/Rust and other compiled languages. Requires GDB 14+ with native DAP support (gdb -i dap). GDB does not support 'source' mode; compile your program with debug symbols (gcc -g -O0) and use 'binary' mode.
+- 'lldb': For C/C++/Rust/Swift, especially programs built with clang/LLVM toolchains. Requires lldb-dap (or the older lldb-vscode). Like GDB, use 'binary' mode with a program built with debug symbols.
+- 'debugpy': For Python. Requires the debugpy package (pip install debugpy). Use 'source' mode with a .py file or a module name as path, or 'attach' with a processId. Set justMyCode: false to step into library code.
+
+Choose the debugger based on the language of the program being debugged: use 'delve' for Go, use 'gdb' or 'lldb' for C/C++/Rust, 'lldb' for Swift, and 'debugpy' for Python.
 
-Choose the debugger based on the language of the program being debugged: use 'delve' for Go, use 'gdb' for C/C++/Rust.
+Reverse execution: with Delve, use mode 'record' (or backend: 'rr' in 'source' or 'binary' mode) to record the run with rr and replay it; 'step' mode 'back' and 'continue' reverse: true then run backwards. A recording is deterministic: record an intermittent failure once, then re-examine the same run with mode 'replay' as often as needed. With GDB, enable recording by evaluating 'record' in the repl context after the program starts.
 
 By default, when stopped at a breakpoint returns a compact stop summary (location only). Set fullContext: true only if you need variables immediately — leave it false unless you plan to call 'context' right after anyway.`
 
 // registerTools registers the debugger tools with the MCP server.
 // logWriter is used to redirect adapter stderr output; pass io.Discard to suppress.
-func registerTools(server *mcp.Server, logWriter io.Writer) *debuggerSession {
-	ds := &debuggerSession{server: server, logWriter: logWriter, lastFrameID: -1}
+// cfg holds the server settings, such as the default debugger.
+func registerTools(server *mcp.Server, logWriter io.Writer, cfg *config) *sessionManager {
+	m := newSessionManager(server, logWriter, cfg)
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "debug",
 		Description: debugToolDescription,
-	}, ds.debug)
+	}, m.debug)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "sessions",
+		Description: "List debug sessions with their mode, program, debugger and state. The session marked '*' is used by tools called without a 'session' parameter.",
+	}, m.listSessions)
 
-	return ds
+	return m
 }
 
-// sessionToolNames returns the names of all currently registered session tools.
-func (ds *debuggerSession) sessionToolNames() []string {
+// sessionToolNames returns the names of the session tools registered for
+// adapters with the given capabilities.
+func sessionToolNames(caps dap.Capabilities) []string {
 	tools := []string{
 		"stop",
 		"breakpoint",
@@ -75,74 +113,140 @@ func (ds *debuggerSession) sessionToolNames() []string {
 		"continue",
 		"step",
 		"pause",
+		"wait",
 		"context",
 		"evaluate",
 		"info",
+		"output",
+		"inspect",
+		"source",
+		"analyze-blocking",
 	}
 
 	// Capability-gated tools
-	if ds.capabilities.SupportsRestartRequest {
+	if caps.SupportsRestartRequest {
 		tools = append(tools, "restart")
 	}
-	if ds.capabilities.SupportsSetVariable {
+	if caps.SupportsSetVariable {
 		tools = append(tools, "set-variable")
 	}
-	if ds.capabilities.SupportsDisassembleRequest {
+	if caps.SupportsDisassembleRequest {
 		tools = append(tools, "disassemble")
 	}
+	if caps.SupportsDataBreakpoints {
+		tools = append(tools, "watch")
+	}
+	if caps.SupportsInstructionBreakpoints {
+		tools = append(tools, "instruction-breakpoint")
+	}
+	if caps.SupportsReadMemoryRequest {
+		tools = append(tools, "read-memory")
+	}
+	if caps.SupportsWriteMemoryRequest {
+		tools = append(tools, "write-memory")
+	}
+	if len(caps.ExceptionBreakpointFilters) > 0 {
+		tools = append(tools, "exception-breakpoints")
+	}
 
 	return tools
 }
 
-// registerSessionTools removes the debug tool and registers all session-specific tools.
-func (ds *debuggerSession) registerSessionTools() {
-	// Remove debug tool
-	ds.server.RemoveTools("debug")
-
+// registerSessionTools registers all session-specific tools. caps decides
+// which capability-gated tools and parameters are advertised; with several
+// sessions it is the union of their capabilities, and each handler still
+// checks its own session's adapter.
+func (m *sessionManager) registerSessionTools(caps dap.Capabilities) {
 	// Always-available tools
-	mcp.AddTool(ds.server, &mcp.Tool{
+	mcp.AddTool(m.server, &mcp.Tool{
 		Name:        "stop",
-		Description: "End the debugging session. By default terminates the debuggee. Pass detach=true to detach without killing the process (leaves it running); detach requires adapter support.",
-	}, ds.stop)
-	mcp.AddTool(ds.server, &mcp.Tool{
-		Name: "breakpoint",
-		Description: `Set a breakpoint. Provide EITHER file+line OR function name (not both).
-
-Examples: {"file": "/path/to/main.go", "line": 42} or {"function": "main.processData"}`,
-	}, ds.breakpoint)
-	mcp.AddTool(ds.server, &mcp.Tool{
+		Description: "End the debugging session. By default terminates the debuggee. Pass detach=true to detach without killing the process (leaves it running); detach requires adapter support. In remote mode, always disconnects and leaves the server and debuggee running.",
+	}, m.stop)
+	// Breakpoint tool: condition, hitCondition and logMessage are only
+	// advertised when the adapter supports them.
+	bpDesc := `Set a breakpoint. Provide EITHER file+line OR function name (not both). Breakpoints accumulate; use 'clear-breakpoints' to remove them.
+
+Examples: {"file": "/path/to/main.go", "line": 42} or {"function": "main.processData"}`
+	if caps.SupportsConditionalBreakpoints {
+		bpDesc += `
+
+Use 'condition' to stop only when an expression is true, instead of continuing repeatedly through a hot loop: {"file": "/path/to/main.go", "line": 42, "condition": "i == 9999"}`
+	}
+	if caps.SupportsHitConditionalBreakpoints {
+		bpDesc += `
+
+Use 'hitCondition' to stop only after the breakpoint has been hit a number of times: {"function": "main.handle", "hitCondition": ">= 100"}`
+	}
+	if caps.SupportsLogPoints {
+		bpDesc += `
+
+Use 'logMessage' to log instead of stopping (file+line only; expressions in {} are interpolated): {"file": "/path/to/main.go", "line": 42, "logMessage": "i={i}"}`
+	}
+	mcp.AddTool(m.server, &mcp.Tool{
+		Name:        "breakpoint",
+		Description: bpDesc,
+		InputSchema: inputSchemaWithout[BreakpointToolParams](unsupportedBreakpointOptions(caps)...),
+	}, withSession(m, (*debuggerSession).breakpoint))
+	mcp.AddTool(m.server, &mcp.Tool{
 		Name: "clear-breakpoints",
-		Description: `Remove breakpoints. Provide 'file' to clear breakpoints in a specific file, or 'all': true to clear all breakpoints.
+		Description: `Remove breakpoints. Provide 'file' to clear breakpoints in a specific file, or 'all': true to clear all breakpoints, including watches and instruction breakpoints. Exception filters stay enabled; use 'exception-breakpoints' to disable them.
 
 Examples: {"file": "/path/to/main.go"} or {"all": true}`,
-	}, ds.clearBreakpoints)
-	mcp.AddTool(ds.server, &mcp.Tool{
+	}, withSession(m, (*debuggerSession).clearBreakpoints))
+	// Reverse execution is only advertised when the adapter supports it,
+	// such as Delve replaying an rr recording.
+	var stepInstructionDesc string
+	if caps.SupportsSteppingGranularity {
+		stepInstructionDesc = `
+
+Set granularity: 'instruction' to step a single machine instruction instead of a line; the result then shows the next instructions. Use it with 'disassemble' when debugging without source or optimized code.`
+	}
+	var reverseContinueDesc, stepBackDesc string
+	if caps.SupportsStepBack {
+		reverseContinueDesc = `
+
+Set reverse: true to run backwards to the previous breakpoint, or to the start of the recording.`
+		stepBackDesc = `
+
+Mode 'back' steps backwards to the previous line. Together with 'continue' reverse: true, this finds where a value went wrong: set a watch or breakpoint, then run backwards.`
+	}
+	mcp.AddTool(m.server, &mcp.Tool{
 		Name: "continue",
 		Description: `Continue program execution until the next breakpoint or termination.
 
 By default returns a compact stop summary (location only). Set fullContext: true only if you need variables immediately — it saves a separate 'context' call but returns much more data. Leave fullContext false (the default) unless you know you need variables right away.
 
-Optionally specify 'to' for run-to-cursor: {"to": {"file": "/path/main.go", "line": 50}} or {"to": {"function": "main.Run"}}`,
-	}, ds.continueExecution)
-	mcp.AddTool(ds.server, &mcp.Tool{
+Optionally specify 'to' for run-to-cursor: {"to": {"file": "/path/main.go", "line": 50}} or {"to": {"function": "main.Run"}}
+
+If the program has not stopped after 'timeout' seconds (default 30), returns 'still running' and leaves it running; call 'wait' to keep waiting or 'pause' to interrupt it.` + reverseContinueDesc,
+		InputSchema: inputSchemaWithout[ContinueParams](unsupportedContinueOptions(caps)...),
+	}, withSession(m, (*debuggerSession).continueExecution))
+	mcp.AddTool(m.server, &mcp.Tool{
 		Name: "step",
 		Description: `Step through code one line at a time.
 
 By default returns a compact stop summary (location only). Set fullContext: true only if you need variables immediately — it saves a separate 'context' call but returns much more data. Leave fullContext false (the default) unless you know you need variables right away.
 
-Modes: 'over' (execute current line, step over function calls), 'in' (step into function calls), 'out' (run until current function returns).`,
-	}, ds.step)
-	mcp.AddTool(ds.server, &mcp.Tool{
+Modes: 'over' (execute current line, step over function calls), 'in' (step into function calls), 'out' (run until current function returns).
+
+If the step has not completed after 'timeout' seconds (default 30), returns 'still running'; call 'wait' or 'pause'.` + stepInstructionDesc + stepBackDesc,
+		InputSchema: inputSchemaWithout[StepParams](unsupportedStepOptions(caps)...),
+	}, withSession(m, (*debuggerSession).step))
+	mcp.AddTool(m.server, &mcp.Tool{
 		Name:        "pause",
-		Description: "Pause a running program. Use 'context' afterwards to inspect the current state.",
-	}, ds.pauseExecution)
-	mcp.AddTool(ds.server, &mcp.Tool{
+		Description: "Pause a running program, e.g. after 'continue' returned 'still running'. Returns the stop location; use 'context' afterwards to inspect the current state.",
+	}, withSession(m, (*debuggerSession).pauseExecution))
+	mcp.AddTool(m.server, &mcp.Tool{
+		Name:        "wait",
+		Description: "Wait for a running program to stop, after 'continue' or 'step' returned 'still running'. Returns the stop summary, or 'still running' again if the timeout (default 30 seconds) expires.",
+	}, withSession(m, (*debuggerSession).wait))
+	mcp.AddTool(m.server, &mcp.Tool{
 		Name: "context",
 		Description: `Get full debugging context at the current stop location. Always returns ALL of the following — source location, full stack trace, and all variables with types and values. There are no flags to control what is included; everything is always returned.
 
 Call with {} (no arguments) to use the current thread and top frame. Only three optional parameters exist: threadId, frameId, maxFrames. Do NOT pass any other parameters. Use 'info' with type 'threads' to discover valid thread IDs.`,
-	}, ds.context)
-	mcp.AddTool(ds.server, &mcp.Tool{
+	}, withSession(m, (*debuggerSession).context))
+	mcp.AddTool(m.server, &mcp.Tool{
 		Name: "evaluate",
 		Description: `Evaluate an expression in the debugged program's context. Returns the result value and type. All parameters except 'expression' are optional.
 
@@ -151,258 +255,470 @@ The default context is 'watch', which evaluates language expressions (C, C++, Go
 Examples: {"expression": "x + y"}, {"expression": "*ptr"}, {"expression": "$rsp"}, {"expression": "(int)value"}
 
 For GDB commands (e.g. print/x), use context 'repl': {"expression": "print/x var", "context": "repl"}`,
-	}, ds.evaluateExpression)
+	}, withSession(m, (*debuggerSession).evaluateExpression))
+
+	mcp.AddTool(m.server, &mcp.Tool{
+		Name: "output",
+		Description: `Read the debugged program's output (stdout, stderr) and debugger console messages received since the previous 'output' call.
+
+Optionally filter by 'category' ('stdout', 'stderr', 'console') and/or a regular expression 'pattern'. Filtered-out lines are skipped, not kept for later calls. Stop summaries report how many unread lines are waiting.`,
+	}, withSession(m, (*debuggerSession).readOutput))
+
+	mcp.AddTool(m.server, &mcp.Tool{
+		Name: "inspect",
+		Description: `Expand a structured variable (struct, slice, map, pointer) to see its fields or elements. 'context' shows only the first level; composite values there are followed by [ref N].
+
+Provide EITHER 'variablesReference' (the N from [ref N]) OR 'path', an expression evaluated in the current frame. 'depth' expands nested children (default 1, max 5). For large collections, page with 'start' and 'count' (default 100).
+
+Examples: {"variablesReference": 1005}, {"path": "req.Header[\"X\"]"}, {"path": "items", "start": 100, "count": 50}, {"path": "cfg", "depth": 3}`,
+	}, withSession(m, (*debuggerSession).inspect))
+
+	mcp.AddTool(m.server, &mcp.Tool{
+		Name: "source",
+		Description: `Show the source around the current stop location, with '=>' marking the current line and '*' marking lines with breakpoints. Reads local files, and asks the debugger for sources it generates (such as runtime code); without any source, shows the disassembly around the instruction pointer.
+
+Call with {} for the top frame of the current thread, or pass 'frameId' (from 'context') for another frame, or 'file' and 'line' for any location. 'lines' sets how many lines to show before and after (default 5).
+
+'continue', 'step', 'wait' and 'debug' accept 'sourceLines' to include the same listing in their stop summary.`,
+	}, withSession(m, (*debuggerSession).source))
+
+	mcp.AddTool(m.server, &mcp.Tool{
+		Name: "analyze-blocking",
+		Description: `Find out what every thread (goroutine, with Delve) is blocked on, to debug hangs and deadlocks. Pauses the program if it is running.
+
+Classifies each thread by the primitive it waits in: chan send/receive, select, mutex, rwmutex, waitgroup, cond, sleep or netpoll for Go; pthread mutex, condvar or join, sleep or I/O wait for C. Threads waiting on the same channel or lock are listed together, with what can be inferred about who they wait for, such as the thread holding a pthread mutex. Reports a deadlock when no thread can make progress.`,
+	}, withSession(m, (*debuggerSession).analyzeBlocking))
 
 	// Info tool with dynamic description based on adapter capabilities
-	infoTypes := "'threads' (list all threads with IDs, default)"
-	if ds.capabilities.SupportsLoadedSourcesRequest {
+	infoTypes := "'threads' (list threads with their current function and location, default)"
+	if caps.SupportsLoadedSourcesRequest {
 		infoTypes += ", 'sources' (loaded source file paths)"
 	}
-	if ds.capabilities.SupportsModulesRequest {
+	if caps.SupportsModulesRequest {
 		infoTypes += ", 'modules' (loaded modules/libraries)"
 	}
 	infoTypes += ", 'registers' (CPU register values at current frame, GDB only)"
-	infoDesc := fmt.Sprintf("List program metadata. Type: %s.", infoTypes)
-	mcp.AddTool(ds.server, &mcp.Tool{
+	infoDesc := fmt.Sprintf(`List program metadata. Type: %s.
+
+Programs can have thousands of threads (goroutines, with Delve). For threads, narrow the listing with 'filter', a regular expression matched against names, functions and file:line, e.g. {"type": "threads", "filter": "Mutex|chanrecv"}; use 'group': true to list each distinct stack once with a count; 'depth' for more frames per thread; and 'start'/'count' to page.`, infoTypes)
+	mcp.AddTool(m.server, &mcp.Tool{
 		Name:        "info",
 		Description: infoDesc,
-	}, ds.info)
+	}, withSession(m, (*debuggerSession).info))
 
 	// Capability-gated tools
-	if ds.capabilities.SupportsRestartRequest {
-		mcp.AddTool(ds.server, &mcp.Tool{
+	if caps.SupportsRestartRequest {
+		mcp.AddTool(m.server, &mcp.Tool{
 			Name:        "restart",
 			Description: "Restart the debugging session from the beginning. Optionally provide new command line arguments via 'args', or omit to reuse the previous arguments.",
-		}, ds.restartDebugger)
+		}, withSession(m, (*debuggerSession).restartDebugger))
 	}
-	if ds.capabilities.SupportsSetVariable {
-		mcp.AddTool(ds.server, &mcp.Tool{
+	if caps.SupportsSetVariable {
+		mcp.AddTool(m.server, &mcp.Tool{
 			Name: "set-variable",
 			Description: `Modify a variable's value in the debugged program. Requires the variablesReference from a previous 'context' call's scope.
 
 Example: {"variablesReference": 1000, "name": "count", "value": "42"}`,
-		}, ds.setVariable)
+		}, withSession(m, (*debuggerSession).setVariable))
 	}
-	if ds.capabilities.SupportsDisassembleRequest {
-		mcp.AddTool(ds.server, &mcp.Tool{
+	if caps.SupportsDisassembleRequest {
+		mcp.AddTool(m.server, &mcp.Tool{
 			Name: "disassemble",
 			Description: `Disassemble machine code at a memory address. Returns assembly instructions.
 
 Example: {"address": "0x00400780"} or {"address": "0x00400780", "count": 30}
 The 'address' is a hex memory address (e.g. from instructionPointerReference in a stack frame). 'count' defaults to 20 instructions.`,
-		}, ds.disassembleCode)
+		}, withSession(m, (*debuggerSession).disassembleCode))
 	}
-}
+	if caps.SupportsDataBreakpoints {
+		mcp.AddTool(m.server, &mcp.Tool{
+			Name: "watch",
+			Description: `Set a data breakpoint (watchpoint): stop whenever a variable's memory is written, or read. Use it to find which code overwrites a value. Watches accumulate across calls; 'clear-breakpoints' with all=true removes them too.
 
-// unregisterSessionTools removes all session tools and re-registers debug.
-func (ds *debuggerSession) unregisterSessionTools() {
-	ds.server.RemoveTools(ds.sessionToolNames()...)
+Provide 'name', a variable in the current frame, or a field together with the 'variablesReference' of its parent from 'context' or 'inspect'. 'accessType' is 'write' (default), 'read' or 'readWrite'. Hardware limits how many watches can be set at once.
 
-	mcp.AddTool(ds.server, &mcp.Tool{
-		Name:        "debug",
-		Description: debugToolDescription,
-	}, ds.debug)
+Examples: {"name": "count"}, {"name": "next", "variablesReference": 1005}, {"name": "buf", "accessType": "readWrite"}, {"name": "count", "remove": true}. Call with {} to list watches.`,
+		}, withSession(m, (*debuggerSession).watch))
+	}
+	if caps.SupportsInstructionBreakpoints {
+		mcp.AddTool(m.server, &mcp.Tool{
+			Name: "instruction-breakpoint",
+			Description: `Set a breakpoint on a machine instruction, for code without source or to stop partway through a line. Take the 'address' from 'disassemble' output or a frame's instructionPointer. Breakpoints accumulate across calls; 'clear-breakpoints' with all=true removes them too.
+
+Examples: {"address": "0x4a1b2c"}, {"address": "0x4a1b2c", "remove": true}. Call with {} to list instruction breakpoints.`,
+			InputSchema: inputSchemaWithout[InstructionBreakpointParams](unsupportedBreakpointOptions(caps)...),
+		}, withSession(m, (*debuggerSession).instructionBreakpoint))
+	}
+	if caps.SupportsReadMemoryRequest {
+		mcp.AddTool(m.server, &mcp.Tool{
+			Name: "read-memory",
+			Description: `Read raw memory from the debugged program, shown as a hex and ASCII dump, or as integers with 'format' (e.g. 'int32', 'uint64') and 'endian' ('little' by default, or 'big').
+
+Provide 'address' (hex), or a 'memoryReference': 'evaluate' shows one as [memory ...] for values that live in memory (e.g. evaluate '&buf'), and a stack frame's instructionPointer is one too. 'offset' shifts the start in bytes; 'count' is the number of bytes (default 64).
+
+Examples: {"address": "0xc000012345", "count": 32}, {"memoryReference": "0x7ffe3a10", "format": "int32"}`,
+		}, withSession(m, (*debuggerSession).readMemory))
+	}
+	if caps.SupportsWriteMemoryRequest {
+		mcp.AddTool(m.server, &mcp.Tool{
+			Name: "write-memory",
+			Description: `Write raw memory in the debugged program. Provide 'address' (hex) or a 'memoryReference' (see 'read-memory'), and either 'data' as hex bytes, or an integer 'value' with its 'format' (e.g. 'int32') and optional 'endian' ('little' by default, or 'big').
+
+Examples: {"address": "0xc000012345", "data": "de ad be ef"}, {"memoryReference": "0x7ffe3a10", "value": "42", "format": "int32"}`,
+		}, withSession(m, (*debuggerSession).writeMemory))
+	}
+	if len(caps.ExceptionBreakpointFilters) > 0 {
+		excDesc := fmt.Sprintf(`Stop when the program raises an exception or panic. Lists the debug adapter's exception filters and enables or disables them; call with {} to see them and which are enabled.
+
+Available filters: %s.
+
+Examples: {"enable": ["%s"]} or {"disable": ["%s"]}`, exceptionFilterIDs(caps.ExceptionBreakpointFilters), caps.ExceptionBreakpointFilters[0].Filter, caps.ExceptionBreakpointFilters[0].Filter)
+		var omit []string
+		if caps.SupportsExceptionFilterOptions {
+			excDesc += `
+
+Use 'condition' to stop only on matching exceptions, for filters that support one: {"enable": ["<filter>"], "condition": "<expression>"}`
+		} else {
+			omit = append(omit, "condition")
+		}
+		mcp.AddTool(m.server, &mcp.Tool{
+			Name:        "exception-breakpoints",
+			Description: excDesc,
+			InputSchema: inputSchemaWithout[ExceptionBreakpointsParams](omit...),
+		}, withSession(m, (*debuggerSession).exceptionBreakpoints))
+	}
 }
 
-// BreakpointSpec specifies a breakpoint location.
+// inputSchemaWithout derives the input schema for T the same way mcp.AddTool
+// does, then removes the named properties so that parameters the adapter
+// cannot honor are not advertised to the client.
+func inputSchemaWithout[T any](omit ...string) *jsonschema.Schema {
+	schema, err := jsonschema.For[T](nil)
+	if err != nil {
+		// mcp.AddTool panics on the same error when inferring the schema itself.
+		panic(fmt.Sprintf("inferring input schema for %T: %v", *new(T), err))
+	}
+	for _, name := range omit {
+		delete(schema.Properties, name)
+	}
+	return schema
+}
+
+// BreakpointSpec specifies a breakpoint location and its optional
+// condition, hit condition and log message.
 type BreakpointSpec struct {
-	File     string `json:"file,omitempty"`
-	Line     int    `json:"line,omitempty"`
-	Function string `json:"function,omitempty"`
+	File         string `json:"file,omitempty"`
+	Line         int    `json:"line,omitempty"`
+	Function     string `json:"function,omitempty"`
+	Condition    string `json:"condition,omitempty"`
+	HitCondition string `json:"hitCondition,omitempty"`
+	LogMessage   string `json:"logMessage,omitempty"`
+}
+
+// sourceBreakpoint converts a file+line spec to a DAP source breakpoint.
+func (s BreakpointSpec) sourceBreakpoint() dap.SourceBreakpoint {
+	return dap.SourceBreakpoint{
+		Line:         s.Line,
+		Condition:    s.Condition,
+		HitCondition: s.HitCondition,
+		LogMessage:   s.LogMessage,
+	}
+}
+
+// functionBreakpoint converts a function spec to a DAP function breakpoint.
+// DAP function breakpoints have no log message.
+func (s BreakpointSpec) functionBreakpoint() dap.FunctionBreakpoint {
+	return dap.FunctionBreakpoint{
+		Name:         s.Function,
+		Condition:    s.Condition,
+		HitCondition: s.HitCondition,
+	}
+}
+
+// validate returns an error if the spec uses an optional field that the
+// adapter does not support, or a log message on a function breakpoint.
+func (s BreakpointSpec) validate(caps dap.Capabilities) error {
+	if s.Function != "" && s.LogMessage != "" {
+		return fmt.Errorf("logMessage is only supported for file+line breakpoints")
+	}
+	set := map[string]bool{
+		"condition":    s.Condition != "",
+		"hitCondition": s.HitCondition != "",
+		"logMessage":   s.LogMessage != "",
+	}
+	for _, field := range unsupportedBreakpointOptions(caps) {
+		if set[field] {
+			return fmt.Errorf("%s is not supported by this debug adapter", field)
+		}
+	}
+	return nil
 }
 
 // DebugParams defines the parameters for starting a complete debug session.
 type DebugParams struct {
-	Mode         string           `json:"mode" mcp:"'source' (compile & debug), 'binary' (debug executable), 'core' (debug core dump), or 'attach' (connect to process)"`
-	Path         string           `json:"path,omitempty" mcp:"program path (required for source/binary modes; optional for core mode with GDB, which can auto-detect it)"`
-	Args         []string         `json:"args,omitempty" mcp:"command line arguments for the program"`
-	CoreFilePath string           `json:"coreFilePath,omitempty" mcp:"path to core dump file (required for core mode)"`
-	ProcessID    int              `json:"processId,omitempty" mcp:"process ID (required for attach mode)"`
-	Breakpoints  []BreakpointSpec `json:"breakpoints,omitempty" mcp:"initial breakpoints"`
-	StopOnEntry  bool             `json:"stopOnEntry,omitempty" mcp:"stop at program entry instead of running to first breakpoint"`
-	Port         string           `json:"port,omitempty" mcp:"port for DAP server (default: auto-assigned)"`
-	Debugger    string `json:"debugger,omitempty" mcp:"debugger to use: 'delve' (default) or 'gdb'"`
-	GDBPath     string `json:"gdbPath,omitempty" mcp:"path to gdb binary (default: auto-detected from PATH). Requires GDB 14+."`
-	ProtocolLog string `json:"protocolLog,omitempty" mcp:"file path for protocol-level DAP message logging (what the MCP server sends/receives)"`
-	ToolLog     string `json:"toolLog,omitempty" mcp:"file path for tool-level DAP logging (native debugger logging, GDB only)"`
-	FullContext bool   `json:"fullContext,omitempty" mcp:"if true, return full context (stack trace and variables) when stopped at a breakpoint; if false (default), return a compact stop summary — leave false unless you need variables immediately"`
+	Mode         string            `json:"mode" mcp:"'source' (compile & debug), 'binary' (debug executable), 'core' (debug core dump), 'attach' (connect to process), 'remote' (connect to a running 'dlv --headless --accept-multiclient' server), 'record' (delve only: record a run with rr, then replay it), or 'replay' (delve only: replay an rr trace)"`
+	Path         string            `json:"path,omitempty" mcp:"program path (required for source/binary/record modes; optional for core mode with GDB, which can auto-detect it)"`
+	Args         []string          `json:"args,omitempty" mcp:"command line arguments for the program"`
+	Env          map[string]string `json:"env,omitempty" mcp:"environment variables for the program, in addition to the server's own; they override envFile"`
+	EnvFile      string            `json:"envFile,omitempty" mcp:"dotenv file of KEY=VALUE lines with environment variables for the program"`
+	Cwd          string            `json:"cwd,omitempty" mcp:"working directory for the program (default: the server's)"`
+	BuildFlags   string            `json:"buildFlags,omitempty" mcp:"delve source and record modes only: flags for 'go build', e.g. '-tags=integration' (default: the server's Go build flags)"`
+	Output       string            `json:"output,omitempty" mcp:"delve source and record modes only: path for the built binary (default: a temporary file)"`
+	CoreFilePath string            `json:"coreFilePath,omitempty" mcp:"path to core dump file (required for core mode)"`
+	ProcessID    int               `json:"processId,omitempty" mcp:"process ID (required for attach mode)"`
+	Breakpoints  []BreakpointSpec  `json:"breakpoints,omitempty" mcp:"initial breakpoints"`
+	Exceptions   []string          `json:"exceptionBreakpoints,omitempty" mcp:"exception filters to enable before the program runs, e.g. ['panic'], in addition to the adapter's defaults; see 'exception-breakpoints'"`
+	StopOnEntry  bool              `json:"stopOnEntry,omitempty" mcp:"stop at program entry instead of running to first breakpoint"`
+	Session      string            `json:"session,omitempty" mcp:"name for the new session (default: 'default'); starting a session with an existing name replaces it"`
+	Port         string            `json:"port,omitempty" mcp:"port for DAP server (default: auto-assigned)"`
+	Address      string            `json:"address,omitempty" mcp:"address of a running DAP server for remote mode: 'host:port' or 'unix:/path/to/socket'"`
+	Debugger     string            `json:"debugger,omitempty" mcp:"debugger to use: 'delve', 'gdb', 'lldb', or 'debugpy' (default: the server's default debugger, normally 'delve')"`
+	GDBPath      string            `json:"gdbPath,omitempty" mcp:"path to gdb binary (default: the server's gdb path, or auto-detected from PATH). Requires GDB 14+."`
+	LLDBPath     string            `json:"lldbPath,omitempty" mcp:"path to lldb-dap binary (default: lldb-dap or lldb-vscode, auto-detected from PATH)"`
+	PythonPath   string            `json:"pythonPath,omitempty" mcp:"path to the Python interpreter with debugpy installed (default: python3 or python, auto-detected from PATH)"`
+	Backend      string            `json:"backend,omitempty" mcp:"delve only: target backend, 'native' (default), 'lldb', or 'rr' to record the run with rr and replay it, which enables reverse execution (step mode 'back', continue reverse: true)"`
+	TraceDir     string            `json:"traceDir,omitempty" mcp:"rr trace directory: the trace to replay (required for replay mode), or where record mode saves its recording (default: a new temporary directory)"`
+	JustMyCode   *bool             `json:"justMyCode,omitempty" mcp:"debugpy only: restrict stepping and breakpoints to your own code, skipping the standard library and installed packages (default: true)"`
+	ProtocolLog  string            `json:"protocolLog,omitempty" mcp:"file path for protocol-level DAP message logging (what the MCP server sends/receives)"`
+	ToolLog      string            `json:"toolLog,omitempty" mcp:"file path for tool-level DAP logging (native debugger logging, GDB and LLDB only)"`
+	FullContext  *bool             `json:"fullContext,omitempty" mcp:"if true, return full context (stack trace and variables) when stopped at a breakpoint; if false, return a compact stop summary (default: compact, unless the server is configured for full context) — leave unset unless you need variables immediately"`
+	SourceLines  FlexInt           `json:"sourceLines,omitempty" mcp:"if set, include this many lines of source before and after the stop location"`
+}
+
+// launchOptions returns the options for launching the program. The
+// variables in envFile are overridden by env, and buildFlags defaults to
+// defaultBuildFlags.
+func (p DebugParams) launchOptions(defaultBuildFlags string) (LaunchOptions, error) {
+	opts := LaunchOptions{Args: p.Args, Cwd: p.Cwd, BuildFlags: p.BuildFlags, Output: p.Output}
+	if opts.BuildFlags == "" {
+		opts.BuildFlags = defaultBuildFlags
+	}
+	if p.EnvFile != "" {
+		env, err := readEnvFile(p.EnvFile)
+		if err != nil {
+			return LaunchOptions{}, err
+		}
+		opts.Env = env
+	}
+	if len(p.Env) > 0 && opts.Env == nil {
+		opts.Env = make(map[string]string, len(p.Env))
+	}
+	maps.Copy(opts.Env, p.Env)
+	return opts, nil
 }
 
+// defaultMaxFrames is the number of stack frames fetched when no maximum
+// is given and the server's maxFrames setting is not set.
+const defaultMaxFrames = 20
+
 // ContextParams defines the parameters for getting debugging context.
 type ContextParams struct {
+	SessionParam
 	ThreadID  FlexInt `json:"threadId,omitempty" mcp:"thread to inspect (default: current thread)"`
 	FrameID   FlexInt `json:"frameId,omitempty" mcp:"frame to focus on (default: top frame)"`
-	MaxFrames FlexInt `json:"maxFrames,omitempty" mcp:"maximum stack frames (default: 20)"`
+	MaxFrames FlexInt `json:"maxFrames,omitempty" mcp:"maximum stack frames (default: the server's maxFrames setting, normally 20)"`
 }
 
 // StepParams defines the parameters for stepping through code.
 type StepParams struct {
-	Mode        string  `json:"mode" mcp:"'over' (next line), 'in' (into function), 'out' (out of function)"`
+	SessionParam
+	Mode        string  `json:"mode" mcp:"'over' (next line), 'in' (into function), 'out' (out of function), or 'back' (previous line, with reverse execution)"`
+	Granularity string  `json:"granularity,omitempty" mcp:"'line' (default) or 'instruction' to step a single machine instruction"`
 	ThreadID    FlexInt `json:"threadId,omitempty" mcp:"thread to step (default: current thread)"`
-	FullContext bool    `json:"fullContext,omitempty" mcp:"if true, return full context (stack trace and variables) when stopped; if false (default), return a compact stop summary — leave false unless you need variables immediately"`
+	Timeout     FlexInt `json:"timeout,omitempty" mcp:"seconds to wait for the step to complete before returning 'still running' (default: 30)"`
+	FullContext *bool   `json:"fullContext,omitempty" mcp:"if true, return full context (stack trace and variables) when stopped; if false, return a compact stop summary (default: compact, unless the server is configured for full context) — leave unset unless you need variables immediately"`
+	SourceLines FlexInt `json:"sourceLines,omitempty" mcp:"if set, include this many lines of source before and after the stop location"`
 }
 
 // InfoParams defines parameters for getting program metadata.
 type InfoParams struct {
-	Type string `json:"type,omitempty" mcp:"'threads' (list threads), 'sources' (loaded source files), 'modules' (loaded modules), or 'registers' (CPU register values at current frame, GDB only)"`
+	SessionParam
+	Type   string  `json:"type,omitempty" mcp:"'threads' (list threads), 'sources' (loaded source files), 'modules' (loaded modules), or 'registers' (CPU register values at current frame, GDB only)"`
+	Filter string  `json:"filter,omitempty" mcp:"threads only: regular expression matched against thread names and the functions and file:line locations of their stacks"`
+	Group  bool    `json:"group,omitempty" mcp:"threads only: group threads with identical stacks and list each stack once with a count"`
+	Depth  FlexInt `json:"depth,omitempty" mcp:"threads only: stack frames shown, filtered and grouped on per thread (default: 1, or 5 with group; max: 10)"`
+	Start  FlexInt `json:"start,omitempty" mcp:"threads only: index of the first thread (or group) to list, for paging (default: 0)"`
+	Count  FlexInt `json:"count,omitempty" mcp:"threads only: maximum threads (or groups) listed (default: 100)"`
 }
 
 // BreakpointToolParams defines parameters for setting a breakpoint.
 type BreakpointToolParams struct {
-	File     string  `json:"file,omitempty" mcp:"source file path (required if no function)"`
-	Line     FlexInt `json:"line,omitempty" mcp:"line number (required if file provided)"`
-	Function string  `json:"function,omitempty" mcp:"function name (alternative to file+line)"`
+	SessionParam
+	File         string  `json:"file,omitempty" mcp:"source file path (required if no function)"`
+	Line         FlexInt `json:"line,omitempty" mcp:"line number (required if file provided)"`
+	Function     string  `json:"function,omitempty" mcp:"function name (alternative to file+line)"`
+	Condition    string  `json:"condition,omitempty" mcp:"only stop when this expression is true, e.g. 'i == 9999'"`
+	HitCondition string  `json:"hitCondition,omitempty" mcp:"only stop once the hit count satisfies this expression, e.g. '>= 10' (syntax is adapter-specific)"`
+	LogMessage   string  `json:"logMessage,omitempty" mcp:"log this message instead of stopping (logpoint); expressions in {} are interpolated. file+line only"`
 }
 
-// readAndValidateResponse reads DAP messages until it receives the response
-// matching requestSeq. Out-of-order responses (different request_seq) and
-// events are skipped. Returns an error if the matched response indicates failure.
+// spec converts the tool parameters to a BreakpointSpec.
+func (p BreakpointToolParams) spec() BreakpointSpec {
+	return BreakpointSpec{
+		File:         p.File,
+		Line:         p.Line.Int(),
+		Function:     p.Function,
+		Condition:    p.Condition,
+		HitCondition: p.HitCondition,
+		LogMessage:   p.LogMessage,
+	}
+}
+
+// readAndValidateResponse waits for the response matching requestSeq.
+// Returns an error if the response indicates failure.
 func readAndValidateResponse(client *DAPClient, requestSeq int, errorPrefix string) error {
-	for {
-		msg, err := client.ReadMessage()
-		if err != nil {
-			return err
-		}
-		switch resp := msg.(type) {
-		case dap.ResponseMessage:
-			r := resp.GetResponse()
-			if r.RequestSeq != requestSeq {
-				log.Printf("readAndValidateResponse: skipping out-of-order response (request_seq=%d, waiting for %d)",
-					r.RequestSeq, requestSeq)
-				continue
-			}
-			if !r.Success {
-				return fmt.Errorf("%s: %s", errorPrefix, r.Message)
-			}
-			return nil
-		case dap.EventMessage:
-			continue
-		}
+	resp, err := client.response(requestSeq)
+	if err != nil {
+		return err
+	}
+	if r := resp.GetResponse(); !r.Success {
+		return fmt.Errorf("%s: %s", errorPrefix, r.Message)
 	}
+	return nil
 }
 
-// readTypedResponse reads DAP messages until it receives a response of type T
-// matching requestSeq. Out-of-order responses (different request_seq) and
-// events are skipped. Returns an error if the matched response indicates failure.
+// readTypedResponse waits for the response matching requestSeq and checks
+// that it is of type T. Returns an error if the response indicates failure.
 //
 // go-dap decodes all failed responses as *dap.ErrorResponse regardless of
-// command, so we match by request_seq rather than Go type alone.
+// command, so failure is checked before the Go type.
 func readTypedResponse[T dap.ResponseMessage](client *DAPClient, requestSeq int) (T, error) {
 	var zero T
-	for {
-		msg, err := client.ReadMessage()
-		if err != nil {
-			return zero, err
-		}
-		switch resp := msg.(type) {
-		case T:
-			r := resp.GetResponse()
-			if r.RequestSeq != requestSeq {
-				log.Printf("readTypedResponse: skipping out-of-order %T (request_seq=%d, waiting for %d)",
-					resp, r.RequestSeq, requestSeq)
-				continue
-			}
-			if !r.Success {
-				return zero, errors.New(r.Message)
-			}
-			return resp, nil
-		case dap.ResponseMessage:
-			r := resp.GetResponse()
-			if r.RequestSeq != requestSeq {
-				log.Printf("readTypedResponse: skipping out-of-order %T (request_seq=%d, waiting for %d)",
-					resp, r.RequestSeq, requestSeq)
-				continue
-			}
-			// Matched request_seq but different Go type (e.g. *dap.ErrorResponse).
-			if !r.Success {
-				return zero, errors.New(r.Message)
-			}
-			return zero, fmt.Errorf("expected %T, got %T (request_seq=%d)", zero, resp, requestSeq)
-		case dap.EventMessage:
-			continue
-		}
+	resp, err := client.response(requestSeq)
+	if err != nil {
+		return zero, err
+	}
+	if r := resp.GetResponse(); !r.Success {
+		return zero, errors.New(r.Message)
 	}
+	typed, ok := resp.(T)
+	if !ok {
+		return zero, fmt.Errorf("expected %T, got %T (request_seq=%d)", zero, resp, requestSeq)
+	}
+	return typed, nil
 }
 
 // ClearBreakpointsParams defines parameters for clearing breakpoints.
 type ClearBreakpointsParams struct {
+	SessionParam
 	File string `json:"file,omitempty" mcp:"clear all breakpoints in this file"`
-	All  bool   `json:"all,omitempty" mcp:"clear all breakpoints"`
+	All  bool   `json:"all,omitempty" mcp:"clear all breakpoints and watches; exception filters stay enabled"`
 }
 
 // StopParams defines parameters for stopping the debug session.
 type StopParams struct {
+	SessionParam
 	Detach bool `json:"detach,omitempty" mcp:"if true, detach from the process without terminating it (leaves the debuggee running); default false terminates the debuggee"`
 }
 
 // clearBreakpoints removes breakpoints.
-func (ds *debuggerSession) clearBreakpoints(ctx context.Context, _ *mcp.CallToolRequest, params ClearBreakpointsParams) (*mcp.CallToolResult, any, error) {
+func (ds *debuggerSession) clearBreakpoints(ctx context.Context, _ *mcp.CallToolRequest, params ClearBreakpointsParams) (*mcp.CallToolResult, *MessageResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	if ds.client == nil {
 		return nil, nil, fmt.Errorf("debugger not started")
 	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
 
 	if params.All {
-		// Clear all function breakpoints
-		seq, err := ds.client.SetFunctionBreakpointsRequest([]string{})
-		if err != nil {
-			return nil, nil, err
+		if err := ds.clearAllBreakpoints(); err != nil {
+			return nil, nil, fmt.Errorf("unable to clear breakpoints: %w", err)
 		}
-		if err := readAndValidateResponse(ds.client, seq, "unable to clear breakpoints"); err != nil {
-			return nil, nil, err
-		}
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: "Cleared all breakpoints"}},
-		}, nil, nil
+		return messageResult("Cleared all breakpoints")
 	}
 
 	if params.File != "" {
-		// Clear breakpoints in specific file by setting empty list
-		seq, err := ds.client.SetBreakpointsRequest(params.File, []int{})
-		if err != nil {
-			return nil, nil, err
-		}
-		if err := readAndValidateResponse(ds.client, seq, "unable to clear breakpoints"); err != nil {
-			return nil, nil, err
+		// Clear breakpoints in specific file by sending its now-empty list
+		ds.breakpoints.clearSource(params.File)
+		if _, err := ds.syncSourceBreakpoints(params.File); err != nil {
+			return nil, nil, fmt.Errorf("unable to clear breakpoints: %w", err)
 		}
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Cleared breakpoints in: %s", params.File)}},
-		}, nil, nil
+		return messageResult(fmt.Sprintf("Cleared breakpoints in: %s", params.File))
 	}
 
 	return nil, nil, fmt.Errorf("specify 'file' or 'all'")
 }
 
+// unsupportedStepOptions lists the StepParams fields that the adapter's
+// capabilities do not support.
+func unsupportedStepOptions(caps dap.Capabilities) []string {
+	if !caps.SupportsSteppingGranularity {
+		return []string{"granularity"}
+	}
+	return nil
+}
+
+// unsupportedContinueOptions lists the ContinueParams fields that the
+// adapter's capabilities do not support.
+func unsupportedContinueOptions(caps dap.Capabilities) []string {
+	if !caps.SupportsStepBack {
+		return []string{"reverse"}
+	}
+	return nil
+}
+
 // ContinueParams defines the parameters for continuing execution.
 type ContinueParams struct {
+	SessionParam
 	ThreadID    FlexInt         `json:"threadId,omitempty" mcp:"thread to continue (default: all threads)"`
 	To          *BreakpointSpec `json:"to,omitempty" mcp:"location to run to (sets temporary breakpoint)"`
-	FullContext bool            `json:"fullContext,omitempty" mcp:"if true, return full context (stack trace and variables) when stopped; if false (default), return a compact stop summary — leave false unless you need variables immediately"`
+	Reverse     bool            `json:"reverse,omitempty" mcp:"run backwards to the previous breakpoint, or to the start of the recording"`
+	Timeout     FlexInt         `json:"timeout,omitempty" mcp:"seconds to wait for the program to stop before returning 'still running' (default: 30); the program keeps running"`
+	FullContext *bool           `json:"fullContext,omitempty" mcp:"if true, return full context (stack trace and variables) when stopped; if false, return a compact stop summary (default: compact, unless the server is configured for full context) — leave unset unless you need variables immediately"`
+	SourceLines FlexInt         `json:"sourceLines,omitempty" mcp:"if set, include this many lines of source before and after the stop location"`
+}
+
+// continueExecution resumes the program and waits, up to the timeout, for
+// it to stop. If the timeout expires the program keeps running.
+func (ds *debuggerSession) continueExecution(ctx context.Context, _ *mcp.CallToolRequest, params ContinueParams) (*mcp.CallToolResult, *StopResult, error) {
+	rs, err := ds.startContinue(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ds.awaitRun(ctx, rs, runTimeout(params.Timeout), ds.newStopDetail(params.FullContext, params.SourceLines))
 }
 
-// continueExecution continues execution and returns full context when stopped.
-func (ds *debuggerSession) continueExecution(ctx context.Context, _ *mcp.CallToolRequest, params ContinueParams) (*mcp.CallToolResult, any, error) {
+// startContinue sets any run-to-cursor breakpoint and sends the continue request.
+func (ds *debuggerSession) startContinue(params ContinueParams) (*runState, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	if ds.client == nil {
-		return nil, nil, fmt.Errorf("debugger not started")
+		return nil, fmt.Errorf("debugger not started")
+	}
+	if ds.running != nil {
+		return nil, errRunning
+	}
+
+	if params.Reverse && !ds.capabilities.SupportsStepBack {
+		return nil, errNoReverse
 	}
 
-	// If "to" is specified, set a temporary breakpoint
+	// If "to" is specified, set a temporary breakpoint alongside the
+	// registered ones. restoreTo drops it again once the program stops.
+	var restoreTo func() error
 	if params.To != nil {
 		to := params.To
+		if err := to.validate(ds.capabilities); err != nil {
+			return nil, err
+		}
 		if to.Function != "" {
-			if _, err := ds.client.SetFunctionBreakpointsRequest([]string{to.Function}); err != nil {
-				return nil, nil, err
+			if _, err := ds.syncFunctionBreakpoints(to.functionBreakpoint()); err != nil {
+				return nil, err
+			}
+			restoreTo = func() error {
+				_, err := ds.syncFunctionBreakpoints()
+				return err
 			}
 		} else if to.File != "" && to.Line > 0 {
-			if _, err := ds.client.SetBreakpointsRequest(to.File, []int{to.Line}); err != nil {
-				return nil, nil, err
+			if _, err := ds.syncSourceBreakpoints(to.File, to.sourceBreakpoint()); err != nil {
+				return nil, err
+			}
+			restoreTo = func() error {
+				_, err := ds.syncSourceBreakpoints(to.File)
+				return err
 			}
-		}
-		if _, err := ds.client.ReadMessage(); err != nil {
-			return nil, nil, err
 		}
 	}
 
@@ -410,80 +726,83 @@ func (ds *debuggerSession) continueExecution(ctx context.Context, _ *mcp.CallToo
 	if threadID == 0 {
 		threadID = ds.defaultThreadID()
 	}
-	continueSeq, err := ds.client.ContinueRequest(threadID)
-	if err != nil {
-		return nil, nil, err
+	send := func() (int, error) { return ds.client.ContinueRequest(threadID) }
+	if params.Reverse {
+		send = func() (int, error) { return ds.client.ReverseContinueRequest(threadID) }
 	}
-
-	for {
-		msg, err := ds.client.ReadMessage()
-		if err != nil {
-			return nil, nil, err
-		}
-		switch resp := msg.(type) {
-		case dap.ResponseMessage:
-			r := resp.GetResponse()
-			if r.RequestSeq != continueSeq {
-				log.Printf("continueExecution: skipping out-of-order response (request_seq=%d, waiting for %d)", r.RequestSeq, continueSeq)
-				continue
-			}
-			if !r.Success {
-				return nil, nil, fmt.Errorf("continue failed: %s", r.Message)
-			}
-		case *dap.StoppedEvent:
-			ds.stoppedThreadID = resp.Body.ThreadId
-			result, err := ds.getFullContext(resp.Body.ThreadId, 0, 20)
-			if err != nil || params.FullContext {
-				return result, nil, err
+	rs, err := ds.resume(send)
+	if err != nil {
+		if restoreTo != nil {
+			if err := restoreTo(); err != nil {
+				log.Printf("startContinue: removing temporary breakpoint: %v", err)
 			}
-			return stopSummary(result, resp.Body.Reason), nil, nil
-		case *dap.TerminatedEvent:
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{&mcp.TextContent{Text: "Program terminated"}},
-			}, nil, nil
 		}
+		return nil, err
 	}
+	rs.onStop = restoreTo
+	return rs, nil
 }
 
 // PauseParams defines the parameters for pausing execution.
 type PauseParams struct {
-	ThreadID FlexInt `json:"threadId" mcp:"thread ID to pause"`
+	SessionParam
+	ThreadID FlexInt `json:"threadId,omitempty" mcp:"thread ID to pause (default: current thread)"`
+	Timeout  FlexInt `json:"timeout,omitempty" mcp:"seconds to wait for the program to stop (default: 30)"`
 }
 
-// pauseExecution pauses execution of a thread.
-func (ds *debuggerSession) pauseExecution(ctx context.Context, _ *mcp.CallToolRequest, params PauseParams) (*mcp.CallToolResult, any, error) {
+// pauseExecution pauses execution of a thread. If the program is running
+// after a 'continue' or 'step' that returned early, it waits for the
+// resulting stop and returns the stop summary.
+func (ds *debuggerSession) pauseExecution(ctx context.Context, _ *mcp.CallToolRequest, params PauseParams) (*mcp.CallToolResult, *StopResult, error) {
 	ds.mu.Lock()
-	defer ds.mu.Unlock()
 	if ds.client == nil {
+		ds.mu.Unlock()
 		return nil, nil, fmt.Errorf("debugger not started")
 	}
-	seq, err := ds.client.PauseRequest(params.ThreadID.Int())
+	threadID := params.ThreadID.Int()
+	if threadID == 0 {
+		threadID = ds.defaultThreadID()
+	}
+	seq, err := ds.client.PauseRequest(threadID)
 	if err != nil {
+		ds.mu.Unlock()
 		return nil, nil, err
 	}
-	if err := readAndValidateResponse(ds.client, seq, "unable to pause execution"); err != nil {
+
+	err = readAndValidateResponse(ds.client, seq, "unable to pause execution")
+	rs := ds.running
+	ds.mu.Unlock()
+	if err != nil {
 		return nil, nil, err
 	}
+	if rs == nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: "Paused execution"}},
+		}, &StopResult{Status: statusStopped, Reason: "pause", ThreadID: threadID}, nil
+	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: "Paused execution"}},
-	}, nil, nil
+	// Report the stop caused by the pause.
+	return ds.awaitRun(ctx, rs, runTimeout(params.Timeout), stopDetail{})
 }
 
 // EvaluateParams defines the parameters for evaluating an expression.
 type EvaluateParams struct {
+	SessionParam
 	Expression string   `json:"expression" mcp:"expression to evaluate"`
 	FrameID    *FlexInt `json:"frameId,omitempty" mcp:"stack frame ID for evaluation context (default: current frame)"`
 	Context    string   `json:"context,omitempty" mcp:"context for evaluation: watch, repl, hover (default: watch)"`
 }
 
 // evaluateExpression evaluates an expression in the context of a stack frame.
-func (ds *debuggerSession) evaluateExpression(ctx context.Context, _ *mcp.CallToolRequest, params EvaluateParams) (*mcp.CallToolResult, any, error) {
+func (ds *debuggerSession) evaluateExpression(ctx context.Context, _ *mcp.CallToolRequest, params EvaluateParams) (*mcp.CallToolResult, *EvaluateResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	if ds.client == nil {
 		return nil, nil, fmt.Errorf("debugger not started")
 	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
 
 	evalContext := params.Context
 	if evalContext == "" {
@@ -503,105 +822,122 @@ func (ds *debuggerSession) evaluateExpression(ctx context.Context, _ *mcp.CallTo
 		return nil, nil, err
 	}
 
-	for {
-		msg, err := ds.client.ReadMessage()
-		if err != nil {
-			return nil, nil, err
-		}
-		switch resp := msg.(type) {
-		case *dap.EvaluateResponse:
-			if !resp.Success {
-				return nil, nil, fmt.Errorf("unable to evaluate expression: %s", resp.Message)
-			}
-			result := resp.Body.Result
-			if resp.Body.Type != "" {
-				result = fmt.Sprintf("%s (type: %s)", resp.Body.Result, resp.Body.Type)
-			}
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{&mcp.TextContent{Text: result}},
-			}, nil, nil
-		case dap.ResponseMessage:
-			r := resp.GetResponse()
-			if r.RequestSeq == evalSeq {
-				if !r.Success {
-					return nil, nil, fmt.Errorf("unable to evaluate expression: %s", r.Message)
-				}
-			}
-			log.Printf("evaluate: skipping out-of-order %T response (request_seq=%d, waiting for %d)",
-				resp, r.RequestSeq, evalSeq)
-			continue
-		case dap.EventMessage:
-			continue
-		}
+	resp, err := readTypedResponse[*dap.EvaluateResponse](ds.client, evalSeq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to evaluate expression: %w", err)
 	}
+	result := resp.Body.Result
+	if resp.Body.Type != "" {
+		result = fmt.Sprintf("%s (type: %s)", resp.Body.Result, resp.Body.Type)
+	}
+	if resp.Body.MemoryReference != "" {
+		result += fmt.Sprintf(" [memory %s]", resp.Body.MemoryReference)
+	}
+	ds.recordIndexed(Variable{VariablesReference: resp.Body.VariablesReference, IndexedVariables: resp.Body.IndexedVariables})
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: result}},
+	}, &EvaluateResult{
+		Result:             resp.Body.Result,
+		Type:               resp.Body.Type,
+		VariablesReference: resp.Body.VariablesReference,
+		NamedVariables:     resp.Body.NamedVariables,
+		IndexedVariables:   resp.Body.IndexedVariables,
+		MemoryReference:    resp.Body.MemoryReference,
+	}, nil
 }
 
 // SetVariableParams defines the parameters for setting a variable.
 type SetVariableParams struct {
+	SessionParam
 	VariablesReference FlexInt `json:"variablesReference" mcp:"reference to the variable container"`
-	Name               string `json:"name" mcp:"name of the variable to set"`
-	Value              string `json:"value" mcp:"new value for the variable"`
+	Name               string  `json:"name" mcp:"name of the variable to set"`
+	Value              string  `json:"value" mcp:"new value for the variable"`
 }
 
 // setVariable sets the value of a variable in the debugged program.
-func (ds *debuggerSession) setVariable(ctx context.Context, _ *mcp.CallToolRequest, params SetVariableParams) (*mcp.CallToolResult, any, error) {
+func (ds *debuggerSession) setVariable(ctx context.Context, _ *mcp.CallToolRequest, params SetVariableParams) (*mcp.CallToolResult, *SetVariableResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	if ds.client == nil {
 		return nil, nil, fmt.Errorf("debugger not started")
 	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
 	seq, err := ds.client.SetVariableRequest(params.VariablesReference.Int(), params.Name, params.Value)
 	if err != nil {
 		return nil, nil, err
 	}
-	if err := readAndValidateResponse(ds.client, seq, "unable to set variable"); err != nil {
-		return nil, nil, err
+	resp, err := readTypedResponse[*dap.SetVariableResponse](ds.client, seq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to set variable: %w", err)
 	}
 	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Set variable %s to %s", params.Name, params.Value)}},
-	}, nil, nil
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Set variable %s to %s", params.Name, resp.Body.Value)}},
+	}, &SetVariableResult{
+		Name:               params.Name,
+		Value:              resp.Body.Value,
+		Type:               resp.Body.Type,
+		VariablesReference: resp.Body.VariablesReference,
+	}, nil
 }
 
 // RestartParams defines the parameters for restarting the debugger.
 type RestartParams struct {
+	SessionParam
 	Args []string `json:"args,omitempty" mcp:"new command line arguments for the program upon restart, or empty to reuse previous arguments"`
 }
 
 // restartDebugger restarts the debugging session.
-func (ds *debuggerSession) restartDebugger(ctx context.Context, _ *mcp.CallToolRequest, params RestartParams) (*mcp.CallToolResult, any, error) {
+func (ds *debuggerSession) restartDebugger(ctx context.Context, _ *mcp.CallToolRequest, params RestartParams) (*mcp.CallToolResult, *MessageResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	if ds.client == nil {
 		return nil, nil, fmt.Errorf("debugger not started")
 	}
-	seq, err := ds.client.RestartRequest(map[string]any{
-		"arguments": map[string]any{
-			"request":     "launch",
-			"mode":        "exec",
-			"stopOnEntry": false,
-			"args":        params.Args,
-			"rebuild":     false,
-		},
-	})
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
+	// DAP restarts with the arguments of the original launch or attach
+	// request, so the mode, environment and working directory carry over.
+	args := maps.Clone(ds.launchArgs)
+	if args == nil {
+		args = make(map[string]any)
+	}
+	args["stopOnEntry"] = false
+	args["rebuild"] = false
+	if len(params.Args) > 0 {
+		args["args"] = params.Args
+	}
+	seq, err := ds.client.RestartRequest(map[string]any{"arguments": args})
 	if err != nil {
 		return nil, nil, err
 	}
 	if err := readAndValidateResponse(ds.client, seq, "unable to restart debugger"); err != nil {
 		return nil, nil, err
 	}
+	ds.launchArgs = args
+	if len(params.Args) > 0 {
+		ds.programArgs = params.Args
+	}
+	ds.terminated = false
+	if err := ds.applyBreakpoints(); err != nil {
+		return nil, nil, fmt.Errorf("restarted, but unable to re-apply breakpoints: %w", err)
+	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: "Restarted debugging session"}},
-	}, nil, nil
+	return messageResult("Restarted debugging session")
 }
 
 // info returns program metadata.
-func (ds *debuggerSession) info(ctx context.Context, _ *mcp.CallToolRequest, params InfoParams) (*mcp.CallToolResult, any, error) {
+func (ds *debuggerSession) info(ctx context.Context, _ *mcp.CallToolRequest, params InfoParams) (*mcp.CallToolResult, *InfoResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	if ds.client == nil {
 		return nil, nil, fmt.Errorf("debugger not started")
 	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
 
 	infoType := params.Type
 	if infoType == "" {
@@ -612,24 +948,10 @@ func (ds *debuggerSession) info(ctx context.Context, _ *mcp.CallToolRequest, par
 		}
 	}
 
+	out := &InfoResult{Type: infoType}
 	switch infoType {
 	case "threads":
-		seq, err := ds.client.ThreadsRequest()
-		if err != nil {
-			return nil, nil, err
-		}
-		resp, err := readTypedResponse[*dap.ThreadsResponse](ds.client, seq)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to get threads: %w", err)
-		}
-		var threads strings.Builder
-		threads.WriteString("Threads:\n")
-		for _, t := range resp.Body.Threads {
-			fmt.Fprintf(&threads, "  Thread %d: %s\n", t.Id, t.Name)
-		}
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: threads.String()}},
-		}, nil, nil
+		return ds.listThreads(params)
 
 	case "sources":
 		if !ds.capabilities.SupportsLoadedSourcesRequest {
@@ -647,10 +969,11 @@ func (ds *debuggerSession) info(ctx context.Context, _ *mcp.CallToolRequest, par
 		sources.WriteString("Loaded Sources:\n")
 		for _, src := range resp.Body.Sources {
 			fmt.Fprintf(&sources, "  %s\n", src.Path)
+			out.Sources = append(out.Sources, src.Path)
 		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{Text: sources.String()}},
-		}, nil, nil
+		}, out, nil
 
 	case "modules":
 		if !ds.capabilities.SupportsModulesRequest {
@@ -668,10 +991,11 @@ func (ds *debuggerSession) info(ctx context.Context, _ *mcp.CallToolRequest, par
 		modules.WriteString("Loaded Modules:\n")
 		for _, mod := range resp.Body.Modules {
 			fmt.Fprintf(&modules, "  %s (%s)\n", mod.Name, mod.Path)
+			out.Modules = append(out.Modules, Module{Name: mod.Name, Path: mod.Path})
 		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{Text: modules.String()}},
-		}, nil, nil
+		}, out, nil
 
 	case "registers":
 		if ds.lastFrameID < 0 {
@@ -692,7 +1016,7 @@ func (ds *debuggerSession) info(ctx context.Context, _ *mcp.CallToolRequest, par
 			if scope.VariablesReference <= 0 {
 				return &mcp.CallToolResult{
 					Content: []mcp.Content{&mcp.TextContent{Text: "No registers available"}},
-				}, nil, nil
+				}, out, nil
 			}
 			varSeq, err := ds.client.VariablesRequest(scope.VariablesReference)
 			if err != nil {
@@ -707,9 +1031,10 @@ func (ds *debuggerSession) info(ctx context.Context, _ *mcp.CallToolRequest, par
 			for _, v := range varResp.Body.Variables {
 				fmt.Fprintf(&regs, "  %s = %s\n", v.Name, v.Value)
 			}
+			out.Registers = newVariables(varResp.Body.Variables)
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{&mcp.TextContent{Text: regs.String()}},
-			}, nil, nil
+			}, out, nil
 		}
 		return nil, nil, fmt.Errorf("registers not available (adapter did not report a Registers scope)")
 
@@ -720,58 +1045,89 @@ func (ds *debuggerSession) info(ctx context.Context, _ *mcp.CallToolRequest, par
 
 // DisassembleParams defines the parameters for disassembling code.
 type DisassembleParams struct {
+	SessionParam
 	Address string  `json:"address" mcp:"memory address to disassemble (e.g. '0x00400780')"`
 	Offset  FlexInt `json:"offset,omitempty" mcp:"instruction offset from address (default: 0)"`
 	Count   FlexInt `json:"count,omitempty" mcp:"number of instructions to disassemble (default: 20)"`
 }
 
 // disassembleCode disassembles code at a memory reference.
-func (ds *debuggerSession) disassembleCode(ctx context.Context, _ *mcp.CallToolRequest, params DisassembleParams) (*mcp.CallToolResult, any, error) {
+func (ds *debuggerSession) disassembleCode(ctx context.Context, _ *mcp.CallToolRequest, params DisassembleParams) (*mcp.CallToolResult, *DisassembleResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	log.Printf("disassemble: address=%s offset=%d", params.Address, params.Offset.Int())
 	if ds.client == nil {
 		return nil, nil, fmt.Errorf("debugger not started")
 	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
 	count := params.Count.Int()
 	if count == 0 {
 		count = 20
 	}
-	seq, err := ds.client.DisassembleRequest(params.Address, params.Offset.Int(), count)
+	instructions, err := ds.disassemble(params.Address, params.Offset.Int(), count)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	disResp, err := readTypedResponse[*dap.DisassembleResponse](ds.client, seq)
-	if err != nil {
-		return nil, nil, fmt.Errorf("unable to disassemble: %w", err)
-	}
-
 	var result strings.Builder
 	result.WriteString("Disassembly:\n")
-	for _, inst := range disResp.Body.Instructions {
+	for _, inst := range instructions {
 		fmt.Fprintf(&result, "  %s  %s", inst.Address, inst.Instruction)
-		if inst.Location != nil && inst.Location.Path != "" {
-			fmt.Fprintf(&result, "  ; %s:%d", inst.Location.Path, inst.Line)
+		if inst.File != "" {
+			fmt.Fprintf(&result, "  ; %s:%d", inst.File, inst.Line)
 		}
 		result.WriteString("\n")
 	}
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{&mcp.TextContent{Text: result.String()}},
-	}, nil, nil
+	}, &DisassembleResult{Instructions: instructions}, nil
+}
+
+// disassemble disassembles count instructions starting offset instructions
+// from address. Callers must hold ds.mu.
+func (ds *debuggerSession) disassemble(address string, offset, count int) ([]Instruction, error) {
+	seq, err := ds.client.DisassembleRequest(address, offset, count)
+	if err != nil {
+		return nil, err
+	}
+	disResp, err := readTypedResponse[*dap.DisassembleResponse](ds.client, seq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to disassemble: %w", err)
+	}
+	instructions := make([]Instruction, len(disResp.Body.Instructions))
+	for i, inst := range disResp.Body.Instructions {
+		instructions[i] = Instruction{Address: inst.Address, Instruction: inst.Instruction}
+		if inst.Location != nil && inst.Location.Path != "" {
+			instructions[i].File, instructions[i].Line = inst.Location.Path, inst.Line
+		}
+	}
+	return instructions, nil
 }
 
 // stop ends the debugging session.
 // If params.Detach is true, a DAP disconnect request is sent with terminateDebuggee=false
 // so the debuggee keeps running after the adapter disconnects.
-func (ds *debuggerSession) stop(ctx context.Context, _ *mcp.CallToolRequest, params StopParams) (*mcp.CallToolResult, any, error) {
+func (ds *debuggerSession) stop(ctx context.Context, _ *mcp.CallToolRequest, params StopParams) (*mcp.CallToolResult, *MessageResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	log.Printf("stop")
 	if ds.cmd == nil && ds.client == nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: "No debug session active"}},
-		}, nil, nil
+		return messageResult("No debug session active")
+	}
+
+	if ds.launchMode == "remote" && ds.client != nil {
+		// The server was started by someone else: disconnect without
+		// terminating the debuggee, and leave the server running.
+		seq, err := ds.client.DisconnectRequest(false)
+		if err != nil {
+			log.Printf("stop: disconnect request failed: %v", err)
+		} else if err := readAndValidateResponse(ds.client, seq, "disconnect"); err != nil {
+			log.Printf("stop: disconnect response error: %v", err)
+		}
+		ds.cleanup()
+		return messageResult("Disconnected from remote debug server (server and debuggee still running)")
 	}
 
 	if params.Detach && ds.client != nil {
@@ -785,16 +1141,12 @@ func (ds *debuggerSession) stop(ctx context.Context, _ *mcp.CallToolRequest, par
 			}
 		}
 		ds.cleanup()
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: "Detached from process (debuggee still running)"}},
-		}, nil, nil
+		return messageResult("Detached from process (debuggee still running)")
 	}
 
 	ds.cleanup()
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: "Debug session stopped"}},
-	}, nil, nil
+	return messageResult("Debug session stopped")
 }
 
 // cleanup kills the DAP adapter process and resets session state.
@@ -824,14 +1176,54 @@ func (ds *debuggerSession) cleanup() {
 	ds.programArgs = nil
 	ds.coreFilePath = ""
 	ds.capabilities = dap.Capabilities{}
+	ds.capsUpdate.take()
 	ds.stoppedThreadID = 0
+	ds.running = nil
 	ds.lastFrameID = -1
-	ds.unregisterSessionTools()
+	ds.launchArgs = nil
+	ds.indexedCounts = nil
+	ds.debugger = ""
+	ds.target = ""
+	ds.terminated = false
+	ds.breakpoints = breakpointRegistry{}
+	ds.output.reset()
+	if ds.manager != nil {
+		ds.manager.deactivate(ds)
+	}
+}
+
+// spawnAndConnect starts the backend's DAP server and connects the
+// session's client to it.
+func (ds *debuggerSession) spawnAndConnect(port string) error {
+	cmd, listenAddr, err := ds.backend.Spawn(port, ds.logWriter)
+	if err != nil {
+		return err
+	}
+	ds.cmd = cmd
+
+	// Connect DAP client based on transport mode
+	switch ds.backend.TransportMode() {
+	case "tcp":
+		client, err := newDAPClient(listenAddr)
+		if err != nil {
+			return err
+		}
+		ds.client = client
+	case "stdio":
+		stdout, stdin := ds.backend.(stdioBackend).StdioPipes()
+		ds.client = newDAPClientFromRWC(&readWriteCloser{
+			Reader:      stdout,
+			WriteCloser: stdin,
+		})
+	default:
+		return fmt.Errorf("unsupported transport mode: %s", ds.backend.TransportMode())
+	}
+	return nil
 }
 
 // debug starts a complete debugging session.
 // It starts the debugger, loads the program, sets initial breakpoints, and runs to the first breakpoint.
-func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, params DebugParams) (*mcp.CallToolResult, any, error) {
+func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, params DebugParams) (*mcp.CallToolResult, *StopResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	// Clean up any existing session before starting a new one
@@ -849,14 +1241,21 @@ func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, pa
 	// Validate mode
 	mode := params.Mode
 	switch mode {
-	case "source", "binary", "core", "attach":
+	case "source", "binary", "core", "attach", "remote", "record", "replay":
 		// valid
 	default:
-		return nil, nil, fmt.Errorf("invalid mode: %s (must be 'source', 'binary', 'core', or 'attach')", mode)
+		return nil, nil, fmt.Errorf("invalid mode: %s (must be 'source', 'binary', 'core', 'attach', 'remote', 'record', or 'replay')", mode)
+	}
+	if !ds.config.modeAllowed(mode) {
+		return nil, nil, fmt.Errorf("%s mode is disabled on this server (allowed modes: %s)", mode, strings.Join(ds.config.AllowedModes, ", "))
 	}
 
 	// Validate required parameters
-	if mode == "attach" {
+	if mode == "remote" {
+		if params.Address == "" {
+			return nil, nil, fmt.Errorf("address is required for remote mode")
+		}
+	} else if mode == "attach" {
 		if params.ProcessID == 0 {
 			return nil, nil, fmt.Errorf("processId is required for attach mode")
 		}
@@ -864,6 +1263,10 @@ func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, pa
 		if params.CoreFilePath == "" {
 			return nil, nil, fmt.Errorf("coreFilePath is required for core mode")
 		}
+	} else if mode == "replay" {
+		if params.TraceDir == "" {
+			return nil, nil, fmt.Errorf("traceDir is required for replay mode")
+		}
 	} else {
 		if params.Path == "" {
 			return nil, nil, fmt.Errorf("path is required for %s mode", mode)
@@ -873,13 +1276,20 @@ func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, pa
 	// Select debugger backend
 	debugger := params.Debugger
 	if debugger == "" {
-		debugger = "delve"
+		debugger = ds.config.Debugger
 	}
 	switch debugger {
 	case "delve":
-		ds.backend = &delveBackend{}
+		ds.backend = &delveBackend{
+			dlvPath: ds.config.DlvPath,
+			backend: params.Backend,
+			logDAP:  ds.config.LogLevel == "debug",
+		}
 	case "gdb":
 		gdbPath := params.GDBPath
+		if gdbPath == "" {
+			gdbPath = ds.config.GDBPath
+		}
 		if gdbPath == "" {
 			var err error
 			gdbPath, err = exec.LookPath("gdb")
@@ -888,8 +1298,49 @@ func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, pa
 			}
 		}
 		ds.backend = &gdbBackend{gdbPath: gdbPath, toolLogPath: params.ToolLog}
+	case "lldb":
+		lldbPath := params.LLDBPath
+		if lldbPath == "" {
+			var err error
+			lldbPath, err = lookPathAny("lldb-dap", "lldb-vscode")
+			if err != nil {
+				return nil, nil, fmt.Errorf("lldb-dap not found in PATH. Install LLDB (which provides lldb-dap) or set the lldbPath parameter")
+			}
+		}
+		ds.backend = &lldbBackend{lldbPath: lldbPath, toolLogPath: params.ToolLog}
+	case "debugpy":
+		pythonPath := params.PythonPath
+		if pythonPath == "" {
+			var err error
+			pythonPath, err = lookPathAny("python3", "python")
+			if err != nil {
+				return nil, nil, fmt.Errorf("Python not found in PATH. Install Python with debugpy or set the pythonPath parameter")
+			}
+		}
+		justMyCode := true
+		if params.JustMyCode != nil {
+			justMyCode = *params.JustMyCode
+		}
+		ds.backend = &debugpyBackend{pythonPath: pythonPath, justMyCode: justMyCode}
 	default:
-		return nil, nil, fmt.Errorf("unsupported debugger: %s (must be 'delve' or 'gdb')", debugger)
+		return nil, nil, fmt.Errorf("unsupported debugger: %s (must be 'delve', 'gdb', 'lldb', or 'debugpy')", debugger)
+	}
+
+	if (params.Backend != "" || mode == "record" || mode == "replay") && debugger != "delve" {
+		return nil, nil, fmt.Errorf("backend and the record and replay modes are only supported with Delve")
+	}
+	if params.TraceDir != "" && mode != "record" && mode != "replay" {
+		return nil, nil, fmt.Errorf("traceDir is only used by the record and replay modes")
+	}
+	if (params.Env != nil || params.EnvFile != "" || params.Cwd != "") && mode != "source" && mode != "binary" && mode != "record" {
+		return nil, nil, fmt.Errorf("env, envFile and cwd are only used by the source, binary and record modes, which launch the program")
+	}
+	if (params.BuildFlags != "" || params.Output != "") && (debugger != "delve" || (mode != "source" && mode != "record")) {
+		return nil, nil, fmt.Errorf("buildFlags and output are only used when Delve builds the program, in the source and record modes")
+	}
+	opts, err := params.launchOptions(ds.config.BuildFlags["go"])
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if params.ToolLog != "" && debugger == "delve" {
@@ -900,32 +1351,28 @@ func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, pa
 		return nil, nil, fmt.Errorf("path is required for core mode with %s (only GDB can auto-detect the executable from a core file)", debugger)
 	}
 
-	// Spawn DAP server via backend
-	cmd, listenAddr, err := ds.backend.Spawn(port, ds.logWriter)
-	if err != nil {
-		return nil, nil, err
+	// Record mode runs the program under rr to completion, then replays
+	// the recording like replay mode.
+	traceDir := params.TraceDir
+	if mode == "record" {
+		if traceDir, err = ds.recordRun(ctx, params.Path, opts, traceDir); err != nil {
+			return nil, nil, err
+		}
 	}
-	ds.cmd = cmd
 
-	// Connect DAP client based on transport mode
-	switch ds.backend.TransportMode() {
-	case "tcp":
-		client, err := newDAPClient(listenAddr)
+	if mode == "remote" {
+		// Connect to the existing server instead of spawning one.
+		client, err := newDAPClient(params.Address)
 		if err != nil {
 			return nil, nil, err
 		}
 		ds.client = client
-	case "stdio":
-		gdb := ds.backend.(*gdbBackend)
-		stdout, stdin := gdb.StdioPipes()
-		ds.client = newDAPClientFromRWC(&readWriteCloser{
-			Reader:      stdout,
-			WriteCloser: stdin,
-		})
-	default:
-		return nil, nil, fmt.Errorf("unsupported transport mode: %s", ds.backend.TransportMode())
+	} else if err := ds.spawnAndConnect(port); err != nil {
+		return nil, nil, err
 	}
 
+	ds.client.Subscribe(ds.handleEvent)
+
 	// Protocol-level DAP message logging
 	if params.ProtocolLog != "" {
 		f, err := os.Create(params.ProtocolLog)
@@ -944,30 +1391,71 @@ func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, pa
 
 	// Store session state
 	ds.launchMode = mode
+	ds.debugger = debugger
+	ds.target = params.Path
+	switch mode {
+	case "core":
+		ds.target = fmt.Sprintf("%s (core %s)", params.Path, params.CoreFilePath)
+	case "attach":
+		ds.target = fmt.Sprintf("pid %d", params.ProcessID)
+	case "remote":
+		ds.target = params.Address
+	case "record":
+		ds.target = fmt.Sprintf("%s (rr trace %s)", params.Path, traceDir)
+	case "replay":
+		ds.target = fmt.Sprintf("rr trace %s", traceDir)
+	}
 	ds.programPath = params.Path
 	ds.programArgs = params.Args
 	ds.coreFilePath = params.CoreFilePath
 
+	// The initialized event may arrive before or after the launch/attach
+	// response, so subscribe to it before sending the request.
+	initialized := make(chan struct{})
+	unsubscribe := ds.client.Subscribe(func(ev dap.EventMessage) {
+		if _, ok := ev.(*dap.InitializedEvent); ok {
+			close(initialized)
+		}
+	})
+	defer unsubscribe()
+
 	// Launch or attach using backend-specific args
 	stopOnEntry := params.StopOnEntry || len(params.Breakpoints) == 0
+	var launchSeq int
 	switch mode {
 	case "source", "binary":
-		launchArgs, err := ds.backend.LaunchArgs(mode, params.Path, stopOnEntry, params.Args)
+		launchArgs, err := ds.backend.LaunchArgs(mode, params.Path, stopOnEntry, opts)
 		if err != nil {
 			return nil, nil, err
 		}
 		req := ds.client.newRequest("launch")
 		request := &dap.LaunchRequest{Request: *req}
 		request.Arguments = toRawMessage(launchArgs)
+		ds.launchArgs = launchArgs
 		if err := ds.client.send(request); err != nil {
 			return nil, nil, err
 		}
+		launchSeq = req.Seq
+	case "record", "replay":
+		launchArgs, err := ds.backend.LaunchArgs("replay", traceDir, stopOnEntry, LaunchOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		req := ds.client.newRequest("launch")
+		request := &dap.LaunchRequest{Request: *req}
+		request.Arguments = toRawMessage(launchArgs)
+		ds.launchArgs = launchArgs
+		if err := ds.client.send(request); err != nil {
+			return nil, nil, err
+		}
+		launchSeq = req.Seq
 	case "core":
 		coreArgs, err := ds.backend.CoreArgs(params.Path, params.CoreFilePath)
 		if err != nil {
 			return nil, nil, err
 		}
 		rawArgs := toRawMessage(coreArgs)
+		ds.launchArgs = coreArgs
 		var request dap.Message
 		if ds.backend.CoreRequestType() == "attach" {
 			req := ds.client.newRequest("attach")
@@ -981,6 +1469,7 @@ func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, pa
 		if err := ds.client.send(request); err != nil {
 			return nil, nil, err
 		}
+		launchSeq = request.GetSeq()
 	case "attach":
 		attachArgs, err := ds.backend.AttachArgs(params.ProcessID)
 		if err != nil {
@@ -989,100 +1478,112 @@ func (ds *debuggerSession) debug(ctx context.Context, _ *mcp.CallToolRequest, pa
 		req := ds.client.newRequest("attach")
 		request := &dap.AttachRequest{Request: *req}
 		request.Arguments = toRawMessage(attachArgs)
+		ds.launchArgs = attachArgs
 		if err := ds.client.send(request); err != nil {
 			return nil, nil, err
 		}
+		launchSeq = req.Seq
+	case "remote":
+		remoteArgs, err := ds.backend.RemoteArgs()
+		if err != nil {
+			return nil, nil, err
+		}
+		req := ds.client.newRequest("attach")
+		request := &dap.AttachRequest{Request: *req}
+		request.Arguments = toRawMessage(remoteArgs)
+		ds.launchArgs = remoteArgs
+		if err := ds.client.send(request); err != nil {
+			return nil, nil, err
+		}
+		launchSeq = req.Seq
 	}
 	// After sending the launch/attach request, we must handle two DAP patterns:
 	//
 	// Delve: launch response arrives immediately, then initialized event.
 	//
 	// GDB native DAP: may send an "initialized" event before or after the
-	// launch response.
+	// launch response, and may withhold the response until configurationDone.
 	//
-	// We unify both by reading messages until we see the initialized event.
-	// The launch response may arrive before or after — if it arrives here,
-	// we consume it. If it arrives later, it will be automatically skipped
-	// as an out-of-order response by subsequent seq-based readers.
-	for {
-		msg, err := ds.client.ReadMessage()
+	// We unify both by waiting for the initialized event, failing early if
+	// the launch response reports an error. A successful response that
+	// arrives later is simply collected in the background.
+	launchErr := make(chan error, 1)
+	go func(client *DAPClient) {
+		launchErr <- readAndValidateResponse(client, launchSeq, "unable to start debug session")
+	}(ds.client)
+	select {
+	case <-initialized:
+	case err := <-launchErr:
+		if err != nil && mode == "remote" {
+			// A 'dlv dap' server runs no target until a client launches
+			// one, so there is nothing to attach to.
+			return nil, nil, fmt.Errorf("%w (remote mode needs a server started with 'dlv --headless --accept-multiclient', not 'dlv dap')", err)
+		}
 		if err != nil {
 			return nil, nil, err
 		}
-		switch resp := msg.(type) {
-		case dap.ResponseMessage:
-			if !resp.GetResponse().Success {
-				return nil, nil, fmt.Errorf("unable to start debug session: %s", resp.GetResponse().Message)
-			}
-			// Launch response consumed; continue reading for initialized event
-		case *dap.InitializedEvent:
-			_ = resp
-			goto initialized
+		select {
+		case <-initialized:
+		case <-ds.client.Done():
+			return nil, nil, ds.client.Err()
 		}
 	}
-initialized:
 
-	// Set breakpoints
+	// Set breakpoints. Register them all first so that each file and the
+	// function list are sent to the adapter once, with their full sets.
 	for _, bp := range params.Breakpoints {
+		if err := bp.validate(ds.capabilities); err != nil {
+			return nil, nil, err
+		}
 		if bp.Function != "" {
-			seq, err := ds.client.SetFunctionBreakpointsRequest([]string{bp.Function})
-			if err != nil {
-				return nil, nil, err
-			}
-			if err := readAndValidateResponse(ds.client, seq, "unable to set function breakpoint"); err != nil {
-				return nil, nil, err
-			}
+			ds.breakpoints.setFunction(bp.functionBreakpoint())
 		} else if bp.File != "" && bp.Line > 0 {
-			seq, err := ds.client.SetBreakpointsRequest(bp.File, []int{bp.Line})
-			if err != nil {
-				return nil, nil, err
-			}
-			if err := readAndValidateResponse(ds.client, seq, "unable to set breakpoint"); err != nil {
-				return nil, nil, err
-			}
+			ds.breakpoints.setSource(bp.File, bp.sourceBreakpoint())
 		}
 	}
+	if err := validateExceptionFilters(ds.capabilities, params.Exceptions, ""); err != nil {
+		return nil, nil, err
+	}
+	for _, filter := range append(defaultExceptionFilters(ds.capabilities), params.Exceptions...) {
+		ds.breakpoints.setException(dap.ExceptionFilterOptions{FilterId: filter})
+	}
+	if err := ds.applyBreakpoints(); err != nil {
+		return nil, nil, err
+	}
+
+	// Watch for the first stop before configurationDone lets the program
+	// run, so that a stop reported immediately afterwards is not missed.
+	rs := watchRun(ds.client)
 
 	// Configuration done
 	configSeq, err := ds.client.ConfigurationDoneRequest()
 	if err != nil {
+		rs.cancel()
 		return nil, nil, err
 	}
 	if err := readAndValidateResponse(ds.client, configSeq, "unable to complete configuration"); err != nil {
+		rs.cancel()
 		return nil, nil, err
 	}
 
-	// If the launch response was deferred (arrived after the initialized event),
-	// it will be automatically consumed and skipped as an out-of-order response by
-	// subsequent readAndValidateResponse/readTypedResponse calls, which match
-	// by request_seq.
+	// Delve announces reverse execution support for the rr backend in a
+	// capabilities event during launch.
+	if caps, ok := ds.capsUpdate.take(); ok {
+		ds.capabilities = mergeCapabilities(ds.capabilities, caps)
+	}
 
 	// Register session-specific tools based on capabilities
-	ds.registerSessionTools()
+	ds.manager.activate(ds)
 
 	// For core dump mode, the program is already stopped at the crash point.
 	// Wait for the StoppedEvent from the adapter before returning context.
 	if mode == "core" {
-		for {
-			msg, err := ds.client.ReadMessage()
-			if err != nil {
-				return nil, nil, err
-			}
-			switch ev := msg.(type) {
-			case *dap.StoppedEvent:
-				ds.stoppedThreadID = ev.Body.ThreadId
-				if ds.stoppedThreadID == 0 {
-					ds.stoppedThreadID = 1
-				}
-				result, err := ds.getFullContext(ds.stoppedThreadID, 0, 20)
-				if err != nil || params.FullContext {
-					return result, nil, err
-				}
-				return stopSummary(result, ev.Body.Reason), nil, nil
-			case dap.EventMessage:
-				continue
-			}
+		<-rs.done
+		stop, err := ds.finishRun(rs, ds.newStopDetail(params.FullContext, params.SourceLines))
+		if err != nil {
+			return nil, nil, err
 		}
+		return stopToolResult(stop), stop, nil
 	}
 
 	// If we have breakpoints and not explicitly stopping on entry, wait for the
@@ -1094,63 +1595,58 @@ initialized:
 	// GDB native DAP: with stopAtBeginningOfMainSubprogram=false, may run directly to breakpoint
 	// without stopping at entry first.
 	//
-	// We handle both by reading the first StoppedEvent. If it's an entry stop,
-	// we send ContinueRequest and wait for the next stop.
+	// We handle both by waiting for the first stop. If it's an entry stop,
+	// we continue and wait for the next one.
 	if len(params.Breakpoints) > 0 && !params.StopOnEntry {
-		var stoppedThreadID int
-		for {
-			msg, err := ds.client.ReadMessage()
+		<-rs.done
+		for rs.stopped != nil && rs.stopped.Body.Reason == "entry" {
+			threadID := rs.stopped.Body.ThreadId
+			rs, err = ds.resume(func() (int, error) { return ds.client.ContinueRequest(threadID) })
 			if err != nil {
 				return nil, nil, err
 			}
-			switch ev := msg.(type) {
-			case *dap.StoppedEvent:
-				if ev.Body.Reason == "entry" {
-					// Stopped at entry — send continue to reach the breakpoint
-					if _, err := ds.client.ContinueRequest(ev.Body.ThreadId); err != nil {
-						return nil, nil, err
-					}
-					continue
-				}
-				stoppedThreadID = ev.Body.ThreadId
-				ds.stoppedThreadID = stoppedThreadID
-				goto stopped
-			case *dap.TerminatedEvent:
-				goto stopped
-			}
+			<-rs.done
 		}
-	stopped:
-		if stoppedThreadID == 0 {
-			stoppedThreadID = 1
-		}
-		result, err := ds.getFullContext(stoppedThreadID, 0, 20)
-		if err != nil || params.FullContext {
-			return result, nil, err
+		stop, err := ds.finishRun(rs, ds.newStopDetail(params.FullContext, params.SourceLines))
+		if err != nil {
+			return nil, nil, err
 		}
-		return stopSummary(result, "breakpoint"), nil, nil
+		return withRecording(stopToolResult(stop), mode, traceDir), stop, nil
 	}
+	rs.cancel()
 
 	// Return simple success message when stopped on entry.
-	// The StoppedEvent from the adapter (if any) will be consumed by the
-	// next readTypedResponse call, which skips EventMessages.
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Debug session started for %s. Use 'breakpoint' to set breakpoints and 'continue' to run.", params.Path)}},
-	}, nil, nil
+	return withRecording(&mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Debug session started for %s. Use 'breakpoint' to set breakpoints and 'continue' to run.", ds.target)}},
+	}, mode, traceDir), &StopResult{Status: statusStarted}, nil
+}
+
+// withRecording tells the caller where record mode saved its recording, so
+// that the same run can be replayed in a later session.
+func withRecording(result *mcp.CallToolResult, mode, traceDir string) *mcp.CallToolResult {
+	if mode == "record" {
+		note := fmt.Sprintf("Recorded the run to %s; start a session with mode 'replay' and this traceDir to replay it again.\n\n", traceDir)
+		result.Content = append([]mcp.Content{&mcp.TextContent{Text: note}}, result.Content...)
+	}
+	return result
 }
 
 // context returns the full debugging context at the current location.
-func (ds *debuggerSession) context(ctx context.Context, _ *mcp.CallToolRequest, params ContextParams) (*mcp.CallToolResult, any, error) {
+func (ds *debuggerSession) context(ctx context.Context, _ *mcp.CallToolRequest, params ContextParams) (*mcp.CallToolResult, *ContextResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
 	threadID := params.ThreadID.Int()
 	if threadID == 0 {
 		threadID = ds.defaultThreadID()
 	}
 	maxFrames := params.MaxFrames.Int()
 	if maxFrames == 0 {
-		maxFrames = 20
+		maxFrames = ds.config.MaxFrames
 	}
-	result, err := ds.getFullContext(threadID, params.FrameID.Int(), maxFrames)
+	c, err := ds.getFullContext(threadID, params.FrameID.Int(), maxFrames)
 	if err != nil {
 		// If the thread ID was invalid, try to help by listing available threads
 		if strings.Contains(err.Error(), "threadId") || strings.Contains(err.Error(), "thread") {
@@ -1161,7 +1657,9 @@ func (ds *debuggerSession) context(ctx context.Context, _ *mcp.CallToolRequest,
 		}
 		return nil, nil, err
 	}
-	return result, nil, nil
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: formatContext(c)}},
+	}, c, nil
 }
 
 // getThreadList returns a formatted string of available threads, or empty string on error.
@@ -1184,247 +1682,361 @@ func (ds *debuggerSession) getThreadList() string {
 	return threads.String()
 }
 
-// step executes a step command and returns the full context at the new location.
-func (ds *debuggerSession) step(ctx context.Context, _ *mcp.CallToolRequest, params StepParams) (*mcp.CallToolResult, any, error) {
+// step executes a step command and waits, up to the timeout, for the
+// program to stop at the new location.
+func (ds *debuggerSession) step(ctx context.Context, _ *mcp.CallToolRequest, params StepParams) (*mcp.CallToolResult, *StopResult, error) {
+	rs, err := ds.startStep(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	detail := ds.newStopDetail(params.FullContext, params.SourceLines)
+	if params.Granularity == "instruction" {
+		detail.instructions = stepInstructionCount
+	}
+	return ds.awaitRun(ctx, rs, runTimeout(params.Timeout), detail)
+}
+
+// startStep sends the step request for params.Mode.
+func (ds *debuggerSession) startStep(params StepParams) (*runState, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	if ds.client == nil {
-		return nil, nil, fmt.Errorf("debugger not started")
+		return nil, fmt.Errorf("debugger not started")
+	}
+	if ds.running != nil {
+		return nil, errRunning
 	}
 
 	threadID := params.ThreadID.Int()
 	if threadID == 0 {
 		threadID = ds.defaultThreadID()
 	}
+	var granularity dap.SteppingGranularity
+	switch params.Granularity {
+	case "", "line":
+	case "instruction":
+		if !ds.capabilities.SupportsSteppingGranularity {
+			return nil, fmt.Errorf("stepping by instruction is not supported by this debug adapter")
+		}
+		granularity = "instruction"
+	default:
+		return nil, fmt.Errorf("invalid granularity: %s (must be 'line' or 'instruction')", params.Granularity)
+	}
 
 	// Execute the appropriate step command
+	var send func() (int, error)
 	switch params.Mode {
 	case "over":
-		stepSeq, err := ds.client.NextRequest(threadID)
-		if err != nil {
-			return nil, nil, err
-		}
-		_ = stepSeq
+		send = func() (int, error) { return ds.client.NextRequest(threadID, granularity) }
 	case "in":
-		stepSeq, err := ds.client.StepInRequest(threadID)
-		if err != nil {
-			return nil, nil, err
-		}
-		_ = stepSeq
+		send = func() (int, error) { return ds.client.StepInRequest(threadID, granularity) }
 	case "out":
-		stepSeq, err := ds.client.StepOutRequest(threadID)
-		if err != nil {
-			return nil, nil, err
+		send = func() (int, error) { return ds.client.StepOutRequest(threadID, granularity) }
+	case "back":
+		if !ds.capabilities.SupportsStepBack {
+			return nil, errNoReverse
 		}
-		_ = stepSeq
+		send = func() (int, error) { return ds.client.StepBackRequest(threadID, granularity) }
 	default:
-		return nil, nil, fmt.Errorf("invalid step mode: %s (must be 'over', 'in', or 'out')", params.Mode)
-	}
-
-	// Wait for stopped or terminated event
-	for {
-		msg, err := ds.client.ReadMessage()
-		if err != nil {
-			return nil, nil, err
-		}
-		switch resp := msg.(type) {
-		case dap.ResponseMessage:
-			if !resp.GetResponse().Success {
-				return nil, nil, fmt.Errorf("step failed: %s", resp.GetResponse().Message)
-			}
-		case *dap.StoppedEvent:
-			ds.stoppedThreadID = resp.Body.ThreadId
-			result, err := ds.getFullContext(resp.Body.ThreadId, 0, 20)
-			if err != nil || params.FullContext {
-				return result, nil, err
-			}
-			return stopSummary(result, resp.Body.Reason), nil, nil
-		case *dap.TerminatedEvent:
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{&mcp.TextContent{Text: "Program terminated"}},
-			}, nil, nil
-		}
+		return nil, fmt.Errorf("invalid step mode: %s (must be 'over', 'in', 'out', or 'back')", params.Mode)
 	}
+	return ds.resume(send)
 }
 
-// getFullContext returns a complete context dump including location, stack trace, scopes, and variables.
-func (ds *debuggerSession) getFullContext(threadID, frameID, maxFrames int) (*mcp.CallToolResult, error) {
+// getFullContext returns the location and stack trace of threadID, and the
+// scopes and variables of frameID (default: the top frame).
+func (ds *debuggerSession) getFullContext(threadID, frameID, maxFrames int) (*ContextResult, error) {
 	if ds.client == nil {
 		return nil, fmt.Errorf("debugger not started")
 	}
 
-	var result strings.Builder
-
-	// Get stack trace
-	stSeq, err := ds.client.StackTraceRequest(threadID, 0, maxFrames)
+	frames, err := ds.stackTrace(threadID, maxFrames)
 	if err != nil {
 		return nil, err
 	}
-	stResp, err := readTypedResponse[*dap.StackTraceResponse](ds.client, stSeq)
-	if err != nil {
-		return nil, fmt.Errorf("unable to get stack trace: %w", err)
-	}
-	frames := stResp.Body.StackFrames
-
-	// Current location
+	c := &ContextResult{ThreadID: threadID, StackTrace: frames}
 	if len(frames) > 0 {
 		top := frames[0]
-		result.WriteString("## Current Location\n")
-		fmt.Fprintf(&result, "Function: %s\n", top.Name)
-		if top.Source != nil {
-			fmt.Fprintf(&result, "File: %s:%d\n", top.Source.Path, top.Line)
-		}
-		result.WriteString("\n")
+		c.Location = &top
 	}
 
-	// Stack trace
-	result.WriteString("## Stack Trace\n")
-	for i, frame := range frames {
-		fmt.Fprintf(&result, "#%d (Frame ID: %d) %s", i, frame.Id, frame.Name)
-		if frame.Source != nil && frame.Source.Path != "" {
-			fmt.Fprintf(&result, " at %s:%d", frame.Source.Path, frame.Line)
-		}
-		if frame.InstructionPointerReference != "" {
-			fmt.Fprintf(&result, " [ip: %s]", frame.InstructionPointerReference)
-		}
-		if frame.PresentationHint == "subtle" {
-			result.WriteString(" (runtime)")
-		}
-		result.WriteString("\n")
-	}
-	result.WriteString("\n")
-
 	// Determine the target frame for scopes/variables
 	targetFrameID := frameID
 	if targetFrameID == 0 && len(frames) > 0 {
-		targetFrameID = frames[0].Id
+		targetFrameID = frames[0].ID
 	}
 	ds.lastFrameID = targetFrameID
+	c.FrameID = targetFrameID
 
 	// Get scopes and variables
-	ds.writeScopesAndVariables(&result, targetFrameID)
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: result.String()}},
-	}, nil
+	c.Scopes, c.ScopesError = ds.getScopesAndVariables(targetFrameID)
+	return c, nil
 }
 
-// stopSummary extracts a compact stop message from a full context result,
-// showing just the current location and a prompt to call 'context'.
-func stopSummary(full *mcp.CallToolResult, reason string) *mcp.CallToolResult {
-	text := ""
-	if len(full.Content) > 0 {
-		if tc, ok := full.Content[0].(*mcp.TextContent); ok {
-			text = tc.Text
-		}
-	}
-	var summary strings.Builder
-	if reason != "" {
-		fmt.Fprintf(&summary, "Stopped: %s\n", reason)
+// stackTrace returns up to maxFrames frames of threadID's stack.
+func (ds *debuggerSession) stackTrace(threadID, maxFrames int) ([]StackFrame, error) {
+	seq, err := ds.client.StackTraceRequest(threadID, 0, maxFrames)
+	if err != nil {
+		return nil, err
 	}
-	for _, line := range strings.Split(text, "\n") {
-		if strings.HasPrefix(line, "Function:") || strings.HasPrefix(line, "File:") {
-			summary.WriteString(line + "\n")
-		}
+	resp, err := readTypedResponse[*dap.StackTraceResponse](ds.client, seq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get stack trace: %w", err)
 	}
-	summary.WriteString("Call 'context' to inspect stack trace and variables.")
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: summary.String()}},
+	frames := make([]StackFrame, len(resp.Body.StackFrames))
+	for i, f := range resp.Body.StackFrames {
+		frames[i] = newStackFrame(f)
 	}
+	return frames, nil
 }
 
-// writeScopesAndVariables fetches scopes and their variables for the given
-// frame and writes them to the result builder. Errors are written inline
-// rather than propagated, since partial context is better than none.
-func (ds *debuggerSession) writeScopesAndVariables(result *strings.Builder, frameID int) {
+// getScopesAndVariables fetches the scopes of the given frame and their
+// variables. Errors are recorded in the results rather than propagated,
+// since partial context is better than none. Registers are left to 'info'.
+func (ds *debuggerSession) getScopesAndVariables(frameID int) ([]Scope, string) {
 	scopesSeq, err := ds.client.ScopesRequest(frameID)
 	if err != nil {
-		result.WriteString("## Variables\n(unable to retrieve scopes)\n")
-		return
+		return nil, "unable to retrieve scopes"
 	}
-
 	scopesResp, err := readTypedResponse[*dap.ScopesResponse](ds.client, scopesSeq)
 	if err != nil {
-		result.WriteString("## Variables\n(unable to retrieve scopes)\n")
-		return
-	}
-
-	scopes := scopesResp.Body.Scopes
-	if len(scopes) == 0 {
-		return
+		return nil, "unable to retrieve scopes"
 	}
 
-	result.WriteString("## Variables\n")
-	for _, scope := range scopes {
+	var scopes []Scope
+	for _, scope := range scopesResp.Body.Scopes {
 		if scope.Name == "Registers" {
 			continue
 		}
-		fmt.Fprintf(result, "### %s\n", scope.Name)
+		sc := Scope{Name: scope.Name, VariablesReference: scope.VariablesReference}
+		if scope.VariablesReference > 0 {
+			varSeq, err := ds.client.VariablesRequest(scope.VariablesReference)
+			if err == nil {
+				var varResp *dap.VariablesResponse
+				if varResp, err = readTypedResponse[*dap.VariablesResponse](ds.client, varSeq); err == nil {
+					sc.Variables = newVariables(varResp.Body.Variables)
+					ds.recordIndexed(sc.Variables...)
+				}
+			}
+			if err != nil {
+				sc.Error = "unable to retrieve variables"
+			}
+		}
+		scopes = append(scopes, sc)
+	}
+	return scopes, ""
+}
+
+// formatContext renders a context as text: current location, stack trace,
+// and variables.
+func formatContext(c *ContextResult) string {
+	var result strings.Builder
+
+	// Current location
+	if c.Location != nil {
+		result.WriteString("## Current Location\n")
+		fmt.Fprintf(&result, "Function: %s\n", c.Location.Name)
+		if c.Location.File != "" {
+			fmt.Fprintf(&result, "File: %s:%d\n", c.Location.File, c.Location.Line)
+		}
+		result.WriteString("\n")
+	}
+
+	// Stack trace
+	result.WriteString("## Stack Trace\n")
+	for i, frame := range c.StackTrace {
+		fmt.Fprintf(&result, "#%d (Frame ID: %d) %s", i, frame.ID, frame.Name)
+		if frame.File != "" {
+			fmt.Fprintf(&result, " at %s:%d", frame.File, frame.Line)
+		}
+		if frame.InstructionPointer != "" {
+			fmt.Fprintf(&result, " [ip: %s]", frame.InstructionPointer)
+		}
+		if frame.Runtime {
+			result.WriteString(" (runtime)")
+		}
+		result.WriteString("\n")
+	}
+	result.WriteString("\n")
+
+	// Variables
+	if c.ScopesError != "" {
+		fmt.Fprintf(&result, "## Variables\n(%s)\n", c.ScopesError)
+		return result.String()
+	}
+	if len(c.Scopes) == 0 {
+		return result.String()
+	}
+	result.WriteString("## Variables\n")
+	for _, scope := range c.Scopes {
 		if scope.VariablesReference <= 0 {
+			fmt.Fprintf(&result, "### %s\n", scope.Name)
 			continue
 		}
-		varSeq, err := ds.client.VariablesRequest(scope.VariablesReference)
-		if err != nil {
-			result.WriteString("  (unable to retrieve variables)\n")
+		fmt.Fprintf(&result, "### %s [ref %d]\n", scope.Name, scope.VariablesReference)
+		if scope.Error != "" {
+			fmt.Fprintf(&result, "  (%s)\n", scope.Error)
 			continue
 		}
-		varResp, err := readTypedResponse[*dap.VariablesResponse](ds.client, varSeq)
-		if err != nil {
-			result.WriteString("  (unable to retrieve variables)\n")
-			continue
+		for _, v := range scope.Variables {
+			fmt.Fprintf(&result, "  %s\n", formatVariable(v))
 		}
-		for _, v := range varResp.Body.Variables {
-			if v.Type != "" {
-				fmt.Fprintf(result, "  %s (%s) = %s\n", v.Name, v.Type, v.Value)
-			} else {
-				fmt.Fprintf(result, "  %s = %s\n", v.Name, v.Value)
-			}
+	}
+	return result.String()
+}
+
+// stopToolResult renders a stop as a tool result: the full context if it
+// was requested, otherwise a compact summary.
+func stopToolResult(stop *StopResult) *mcp.CallToolResult {
+	var text string
+	switch {
+	case stop.Status == statusTerminated:
+		text = terminatedText(stop.UnreadOutput)
+	case stop.Context != nil:
+		text = formatContext(stop.Context)
+		if stop.Source != nil {
+			text += "\n" + formatSource(stop.Source)
+		}
+		if len(stop.Instructions) > 0 {
+			text += "\n" + formatInstructions(stop.Instructions)
+		}
+		if stop.Exception != nil {
+			text = formatException(stop.Exception) + "\n" + text
+		}
+	default:
+		text = stopSummary(stop)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}
+}
+
+// terminatedText reports that the debuggee exited, noting any output that
+// has not been read yet.
+func terminatedText(unreadOutput int) string {
+	text := "Program terminated"
+	if unreadOutput > 0 {
+		text += fmt.Sprintf("\nProgram output: %d new lines (call 'output' to read).", unreadOutput)
+	}
+	return text
+}
+
+// stopSummary renders a compact stop message: the current location, any
+// source listing and exception details, the number of unread output lines, and a prompt to
+// call 'context'.
+func stopSummary(stop *StopResult) string {
+	var summary strings.Builder
+	if stop.Reason != "" {
+		fmt.Fprintf(&summary, "Stopped: %s\n", stop.Reason)
+	}
+	if loc := stop.Location; loc != nil {
+		fmt.Fprintf(&summary, "Function: %s\n", loc.Name)
+		if loc.File != "" {
+			fmt.Fprintf(&summary, "File: %s:%d\n", loc.File, loc.Line)
 		}
 	}
+	if stop.Source != nil {
+		summary.WriteString("\n" + formatSource(stop.Source) + "\n")
+	}
+	if len(stop.Instructions) > 0 {
+		summary.WriteString("\n" + formatInstructions(stop.Instructions) + "\n")
+	}
+	if stop.Exception != nil {
+		summary.WriteString("\n" + formatException(stop.Exception) + "\n")
+	}
+	if stop.UnreadOutput > 0 {
+		fmt.Fprintf(&summary, "Program output: %d new lines (call 'output' to read).\n", stop.UnreadOutput)
+	}
+	summary.WriteString("Call 'context' to inspect stack trace and variables.")
+	return summary.String()
 }
 
 // breakpoint sets a breakpoint at the specified location.
-func (ds *debuggerSession) breakpoint(ctx context.Context, _ *mcp.CallToolRequest, params BreakpointToolParams) (*mcp.CallToolResult, any, error) {
+// The breakpoint is added to the session's registry and the full set for its
+// kind is sent to the adapter, so earlier breakpoints are preserved.
+func (ds *debuggerSession) breakpoint(ctx context.Context, _ *mcp.CallToolRequest, params BreakpointToolParams) (*mcp.CallToolResult, *BreakpointResult, error) {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 	if ds.client == nil {
 		return nil, nil, fmt.Errorf("debugger not started")
 	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
+
+	spec := params.spec()
+	if err := spec.validate(ds.capabilities); err != nil {
+		return nil, nil, err
+	}
 
 	if params.Function != "" {
-		seq, err := ds.client.SetFunctionBreakpointsRequest([]string{params.Function})
-		if err != nil {
+		// On failure, put back the breakpoint this one replaced, if any,
+		// and the adapter's set with it.
+		prev := ds.breakpoints.functionBreakpoints()
+		fail := func(err error) (*mcp.CallToolResult, *BreakpointResult, error) {
+			ds.breakpoints.functions = prev
+			if _, syncErr := ds.syncFunctionBreakpoints(); syncErr != nil {
+				return nil, nil, syncErr
+			}
 			return nil, nil, err
 		}
-		if err := readAndValidateResponse(ds.client, seq, "unable to set function breakpoint"); err != nil {
-			return nil, nil, err
+		ds.breakpoints.setFunction(spec.functionBreakpoint())
+		bps, err := ds.syncFunctionBreakpoints()
+		if err != nil {
+			return fail(err)
+		}
+		out := &BreakpointResult{Verified: true, Function: params.Function}
+		for i, fbp := range ds.breakpoints.functionBreakpoints() {
+			if fbp.Name != params.Function || i >= len(bps) {
+				continue
+			}
+			if !bps[i].Verified {
+				return fail(fmt.Errorf("function breakpoint not verified: %s", bps[i].Message))
+			}
+			out = newBreakpointResult(bps[i])
+			out.Function = params.Function
 		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Breakpoint set on function: %s", params.Function)}},
-		}, nil, nil
+		}, out, nil
 	}
 
 	if params.File == "" || params.Line.Int() == 0 {
 		return nil, nil, fmt.Errorf("either function or file+line is required")
 	}
 
-	bpSeq, err := ds.client.SetBreakpointsRequest(params.File, []int{params.Line.Int()})
-	if err != nil {
+	line := params.Line.Int()
+	prev := ds.breakpoints.sourceBreakpoints(params.File)
+	fail := func(err error) (*mcp.CallToolResult, *BreakpointResult, error) {
+		ds.breakpoints.replaceSources(params.File, prev)
+		if _, syncErr := ds.syncSourceBreakpoints(params.File); syncErr != nil {
+			return nil, nil, syncErr
+		}
 		return nil, nil, err
 	}
-
-	resp, err := readTypedResponse[*dap.SetBreakpointsResponse](ds.client, bpSeq)
+	ds.breakpoints.setSource(params.File, spec.sourceBreakpoint())
+	bps, err := ds.syncSourceBreakpoints(params.File)
 	if err != nil {
-		return nil, nil, fmt.Errorf("unable to set breakpoint: %w", err)
-	}
-	if len(resp.Body.Breakpoints) == 0 {
-		return nil, nil, fmt.Errorf("no breakpoints returned")
+		return fail(err)
 	}
-	bp := resp.Body.Breakpoints[0]
-	if !bp.Verified {
-		return nil, nil, fmt.Errorf("breakpoint not verified: %s", bp.Message)
+	for i, sbp := range ds.breakpoints.sourceBreakpoints(params.File) {
+		if sbp.Line != line {
+			continue
+		}
+		if i >= len(bps) {
+			break
+		}
+		bp := bps[i]
+		if !bp.Verified {
+			return fail(fmt.Errorf("breakpoint not verified: %s", bp.Message))
+		}
+		out := newBreakpointResult(bp)
+		if out.File == "" {
+			out.File = params.File
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Breakpoint %d set at %s:%d", bp.Id, params.File, bp.Line)}},
+		}, out, nil
 	}
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Breakpoint %d set at %s:%d", bp.Id, params.File, bp.Line)}},
-	}, nil, nil
+	return fail(fmt.Errorf("no breakpoints returned"))
 }
@@ -1,20 +1,27 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/google/go-dap"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -57,6 +64,13 @@ func compileTestProgram(t *testing.T, cwd, name string) (binaryPath string, clea
 // setupMCPServerAndClient creates and connects MCP server and client
 func setupMCPServerAndClient(t *testing.T) *testSetup {
 	t.Helper()
+	return setupMCPServerAndClientWithConfig(t, defaultConfig())
+}
+
+// setupMCPServerAndClientWithConfig creates and connects MCP server and
+// client, with the server using cfg
+func setupMCPServerAndClientWithConfig(t *testing.T, cfg *config) *testSetup {
+	t.Helper()
 
 	// Get current working directory
 	cwd, err := os.Getwd()
@@ -70,7 +84,7 @@ func setupMCPServerAndClient(t *testing.T) *testSetup {
 		Version: "v1.0.0",
 	}
 	server := mcp.NewServer(&implementation, nil)
-	registerTools(server, io.Discard)
+	registerTools(server, io.Discard, cfg)
 
 	// Create httptest server
 	getServer := func(request *http.Request) *mcp.Server {
@@ -239,6 +253,18 @@ func requireGDBDeps(t *testing.T) {
 	}
 }
 
+// requireDebugpyDeps skips the test if Python or debugpy is not available.
+func requireDebugpyDeps(t *testing.T) {
+	t.Helper()
+	python, err := lookPathAny("python3", "python")
+	if err != nil {
+		t.Skip("python not found in PATH")
+	}
+	if err := exec.Command(python, "-c", "import debugpy").Run(); err != nil {
+		t.Skip("debugpy not installed")
+	}
+}
+
 // compileTestCProgram compiles a C test program with debug symbols and returns the binary path.
 func compileTestCProgram(t *testing.T, cwd, name string) (binaryPath string, cleanup func()) {
 	t.Helper()
@@ -455,6 +481,98 @@ func TestRestart(t *testing.T) {
 	ts.stopDebugger(t)
 }
 
+func TestRestartKeepsEnvironment(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "env")
+	defer cleanupBinary()
+
+	dir := t.TempDir()
+	f := filepath.Join(ts.cwd, "testdata", "go", "env", "main.go")
+	text, isErr := ts.callTool(t, "debug", map[string]any{
+		"mode":        "binary",
+		"path":        binaryPath,
+		"env":         map[string]any{"MODE": "restart"},
+		"cwd":         dir,
+		"breakpoints": []map[string]any{{"file": f, "line": 11}},
+	})
+	if isErr {
+		t.Fatalf("debug returned error: %s", text)
+	}
+
+	// Delve does not forward the restarted program's output, so check its
+	// environment and working directory at the breakpoint instead.
+	if text, isErr = ts.callTool(t, "restart", map[string]any{}); isErr {
+		t.Fatalf("restart returned error: %s", text)
+	}
+	if text, isErr = ts.callTool(t, "continue", map[string]any{}); isErr {
+		t.Fatalf("continue returned error: %s", text)
+	}
+	for expr, want := range map[string]string{"mode": `"restart"`, "cwd": strconv.Quote(dir)} {
+		text, isErr := ts.callTool(t, "evaluate", map[string]any{"expression": expr})
+		if isErr || !strings.HasPrefix(text, want) {
+			t.Errorf("Expected %s to be %s after restart, got: %s", expr, want, text)
+		}
+	}
+	ts.stopDebugger(t)
+}
+
+func TestRestartResendsLaunchArguments(t *testing.T) {
+	client, requests, serverWriter := newPipeClient(t)
+	ds := &debuggerSession{client: client, launchArgs: map[string]any{
+		"request":      "launch",
+		"mode":         "replay",
+		"traceDirPath": "/tmp/trace",
+		"stopOnEntry":  true,
+		"env":          map[string]string{"MODE": "restart"},
+		"cwd":          "/tmp",
+	}}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, _, err := ds.restartDebugger(context.Background(), nil, RestartParams{Args: []string{"again"}})
+		errc <- err
+	}()
+	req, ok := (<-requests).(*dap.RestartRequest)
+	if !ok {
+		t.Fatalf("expected a restart request")
+	}
+	resp := &dap.RestartResponse{}
+	resp.Type = "response"
+	resp.Command = "restart"
+	resp.RequestSeq = req.Seq
+	resp.Success = true
+	dap.WriteProtocolMessage(serverWriter, resp)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Arguments struct {
+			Mode         string            `json:"mode"`
+			TraceDirPath string            `json:"traceDirPath"`
+			StopOnEntry  bool              `json:"stopOnEntry"`
+			Env          map[string]string `json:"env"`
+			Cwd          string            `json:"cwd"`
+			Args         []string          `json:"args"`
+		} `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Arguments, &got); err != nil {
+		t.Fatal(err)
+	}
+	a := got.Arguments
+	if a.Mode != "replay" || a.TraceDirPath != "/tmp/trace" || a.Env["MODE"] != "restart" || a.Cwd != "/tmp" {
+		t.Errorf("expected the original launch arguments, got: %s", req.Arguments)
+	}
+	if a.StopOnEntry || !slices.Equal(a.Args, []string{"again"}) {
+		t.Errorf("expected stopOnEntry false and the new args, got: %s", req.Arguments)
+	}
+	if !slices.Equal(ds.programArgs, []string{"again"}) {
+		t.Errorf("expected the session to keep the new args, got: %v", ds.programArgs)
+	}
+}
+
 func TestContext(t *testing.T) {
 	// Setup test infrastructure
 	ts := setupMCPServerAndClient(t)
@@ -500,10 +618,48 @@ func TestContext(t *testing.T) {
 		t.Errorf("Expected context to contain greeting value 'hello, world', got: %s", contextStr)
 	}
 
+	// The same context is available as structured content
+	var c ContextResult
+	ts.callToolStructured(t, "context", map[string]any{}, &c)
+	if c.Location == nil || c.Location.Name != "main.main" || c.Location.Line != 7 || !strings.HasSuffix(c.Location.File, "main.go") {
+		t.Errorf("Expected structured location main.main at main.go:7, got: %+v", c.Location)
+	}
+	if len(c.StackTrace) == 0 || c.StackTrace[0].ID != c.FrameID {
+		t.Errorf("Expected the selected frame to be the top of the stack trace, got frameId %d and %+v", c.FrameID, c.StackTrace)
+	}
+
+	// The source tool lists the code around the stop, marking the current
+	// line and the breakpoint on it
+	var src SourceResult
+	ts.callToolStructured(t, "source", map[string]any{"lines": 2}, &src)
+	if len(src.Lines) != 4 || src.Lines[0].Line != 5 || src.Lines[2] != (SourceLine{Line: 7, Text: "\tfmt.Println(greeting)", Current: true, Breakpoint: true}) {
+		t.Errorf("Expected lines 5-8 with line 7 current and a breakpoint on it, got: %+v", src.Lines)
+	}
+
 	// Stop debugger
 	ts.stopDebugger(t)
 }
 
+// callToolStructured calls a tool and decodes its structured content into out.
+func (ts *testSetup) callToolStructured(t *testing.T, name string, args map[string]any, out any) {
+	t.Helper()
+
+	result, err := ts.session.CallTool(ts.ctx, &mcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("Failed to call %s: %v", name, err)
+	}
+	if result.IsError {
+		t.Fatalf("%s returned error: %v", name, result.Content)
+	}
+	data, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		t.Fatalf("Failed to marshal %s structured content: %v", name, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatalf("Failed to decode %s structured content %s: %v", name, data, err)
+	}
+}
+
 func TestVariables(t *testing.T) {
 	// Setup test infrastructure
 	ts := setupMCPServerAndClient(t)
@@ -557,6 +713,74 @@ func TestVariables(t *testing.T) {
 	ts.stopDebugger(t)
 }
 
+func TestInspect(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "scopes")
+	defer cleanupBinary()
+
+	f := filepath.Join(ts.cwd, "testdata", "go", "scopes", "main.go")
+	ts.startDebugSession(t, "0", binaryPath, []map[string]any{
+		{"file": f, "line": 67},
+	})
+
+	// Composite variables in context carry a reference for drilling in.
+	contextStr := ts.getContextContent(t)
+	if !strings.Contains(contextStr, "[ref ") {
+		t.Errorf("Expected context to show variable references, got: %s", contextStr)
+	}
+
+	// Expand the slice by path.
+	text, isErr := ts.callTool(t, "inspect", map[string]any{"path": "nums"})
+	if isErr {
+		t.Fatalf("inspect returned error: %s", text)
+	}
+	for _, want := range []string{"[0]", "[4]", "= 5"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected inspect output to contain %q, got: %s", want, text)
+		}
+	}
+
+	// Page through the slice.
+	text, isErr = ts.callTool(t, "inspect", map[string]any{"path": "nums", "start": 1, "count": 2})
+	if isErr {
+		t.Fatalf("inspect with paging returned error: %s", text)
+	}
+	if strings.Contains(text, "[0]") || !strings.Contains(text, "[1]") || !strings.Contains(text, "[2]") {
+		t.Errorf("Expected only elements [1] and [2], got: %s", text)
+	}
+	if !strings.Contains(text, "2 more elements") {
+		t.Errorf("Expected a note about remaining elements, got: %s", text)
+	}
+
+	// Follow the note, which pages by reference rather than by path.
+	m := regexp.MustCompile(`inspect variablesReference (\d+) with start=(\d+)`).FindStringSubmatch(text)
+	if m == nil {
+		t.Fatalf("Expected a paging hint, got: %s", text)
+	}
+	ref, _ := strconv.Atoi(m[1])
+	start, _ := strconv.Atoi(m[2])
+	text, isErr = ts.callTool(t, "inspect", map[string]any{"variablesReference": ref, "start": start})
+	if isErr {
+		t.Fatalf("inspect by reference returned error: %s", text)
+	}
+	if strings.Contains(text, "[0]") || strings.Contains(text, "[2]") || !strings.Contains(text, "[3]") || !strings.Contains(text, "[4]") {
+		t.Errorf("Expected only elements [3] and [4], got: %s", text)
+	}
+
+	// Expand a map entry by path.
+	text, isErr = ts.callTool(t, "inspect", map[string]any{"path": `dict["two"]`})
+	if isErr {
+		t.Fatalf("inspect of map entry returned error: %s", text)
+	}
+	if !strings.Contains(text, "= 2") {
+		t.Errorf(`Expected dict["two"] to be 2, got: %s`, text)
+	}
+
+	ts.stopDebugger(t)
+}
+
 func TestStep(t *testing.T) {
 	// Setup test infrastructure
 	ts := setupMCPServerAndClient(t)
@@ -792,7 +1016,8 @@ func TestToolListChangesWithCapabilities(t *testing.T) {
 	defer cleanupBinary()
 	ts.startDebugSession(t, "0", binaryPath, nil)
 
-	// After debug session: session tools should be available, debug should not
+	// After debug session: session tools should be available alongside debug,
+	// which stays registered so further sessions can be started
 	toolList, err = ts.session.ListTools(ts.ctx, &mcp.ListToolsParams{})
 	if err != nil {
 		t.Fatalf("Failed to list tools after debug: %v", err)
@@ -803,8 +1028,11 @@ func TestToolListChangesWithCapabilities(t *testing.T) {
 		toolNames[tool.Name] = true
 	}
 
-	if toolNames["debug"] {
-		t.Error("Did not expect 'debug' tool during active session")
+	if !toolNames["debug"] {
+		t.Error("Expected 'debug' tool during active session")
+	}
+	if !toolNames["sessions"] {
+		t.Error("Expected 'sessions' tool during active session")
 	}
 	if !toolNames["stop"] {
 		t.Error("Expected 'stop' tool during active session")
@@ -896,6 +1124,40 @@ func TestGDBBasic(t *testing.T) {
 	ts.stopDebugger(t)
 }
 
+func TestDebugpyBasic(t *testing.T) {
+	requireDebugpyDeps(t)
+
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	f := filepath.Join(ts.cwd, "testdata", "python", "helloworld", "main.py")
+
+	// Start a debugpy session with a breakpoint at line 9 (total = add(x, y))
+	text, isErr := ts.callTool(t, "debug", map[string]any{
+		"debugger": "debugpy",
+		"mode":     "source",
+		"path":     f,
+		"breakpoints": []map[string]any{
+			{"file": f, "line": 9},
+		},
+	})
+	if isErr {
+		t.Fatalf("debugpy debug session returned error: %s", text)
+	}
+
+	contextStr := ts.getContextContent(t)
+	t.Logf("debugpy context:\n%s", contextStr)
+
+	if !strings.Contains(contextStr, "main") {
+		t.Errorf("Expected context to contain 'main', got: %s", contextStr)
+	}
+	if !strings.Contains(contextStr, "x") {
+		t.Errorf("Expected context to contain local variable 'x', got: %s", contextStr)
+	}
+
+	ts.stopDebugger(t)
+}
+
 func TestGDBStep(t *testing.T) {
 	requireGDBDeps(t)
 
@@ -1092,6 +1354,54 @@ func TestGDBEvaluateWatchContext(t *testing.T) {
 	ts.stopDebugger(t)
 }
 
+// TestGDBMemory reads and writes a local variable's memory through the
+// memoryReference GDB reports for a pointer to it.
+func TestGDBMemory(t *testing.T) {
+	requireGDBDeps(t)
+
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestCProgram(t, ts.cwd, "helloworld")
+	defer cleanupBinary()
+
+	f := filepath.Join(ts.cwd, "testdata", "c", "helloworld", "main.c")
+	if _, isErr := ts.callTool(t, "debug", map[string]any{
+		"debugger":    "gdb",
+		"mode":        "binary",
+		"path":        binaryPath,
+		"breakpoints": []map[string]any{{"file": f, "line": 12}},
+	}); isErr {
+		t.Fatal("debug returned error")
+	}
+
+	var addr EvaluateResult
+	ts.callToolStructured(t, "evaluate", map[string]any{"expression": "&x"}, &addr)
+	if addr.MemoryReference == "" {
+		t.Fatalf("evaluate &x returned no memoryReference: %+v", addr)
+	}
+
+	var mem MemoryResult
+	ts.callToolStructured(t, "read-memory", map[string]any{"memoryReference": addr.MemoryReference, "count": 4, "format": "int32"}, &mem)
+	if !slices.Equal(mem.Values, []string{"10"}) {
+		t.Errorf("read-memory of x = %+v, want the value 10", mem)
+	}
+
+	if text, isErr := ts.callTool(t, "write-memory", map[string]any{"memoryReference": addr.MemoryReference, "value": "42", "format": "int32"}); isErr {
+		t.Fatalf("write-memory returned error: %s", text)
+	}
+	if text, _ := ts.callTool(t, "evaluate", map[string]any{"expression": "x"}); !strings.Contains(text, "42") {
+		t.Errorf("x after write-memory = %s, want 42", text)
+	}
+
+	text, isErr := ts.callTool(t, "read-memory", map[string]any{"memoryReference": addr.MemoryReference, "count": 8})
+	if isErr || !strings.Contains(text, "2a 00 00 00") {
+		t.Errorf("hex dump of x = %s, want bytes 2a 00 00 00", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
 // Basic 'core' debugging test for GDB.
 func TestGDBCoreDump(t *testing.T) {
 	requireGDBDeps(t)
@@ -1406,6 +1716,157 @@ func TestClearBreakpoints(t *testing.T) {
 	ts.stopDebugger(t)
 }
 
+func TestMultipleBreakpointsSameFile(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "step")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	// Setting a second breakpoint in the same file must not remove the first.
+	f := filepath.Join(ts.cwd, "testdata", "go", "step", "main.go")
+	for _, line := range []int{7, 13} {
+		text, isErr := ts.callTool(t, "breakpoint", map[string]any{"file": f, "line": line})
+		if isErr {
+			t.Fatalf("Failed to set breakpoint at line %d: %s", line, text)
+		}
+	}
+
+	text, isErr := ts.callTool(t, "continue", map[string]any{})
+	if isErr {
+		t.Fatalf("continue returned error: %s", text)
+	}
+	if !strings.Contains(text, "main.go:7") {
+		t.Errorf("Expected to stop at main.go:7, got: %s", text)
+	}
+
+	text, isErr = ts.callTool(t, "continue", map[string]any{})
+	if isErr {
+		t.Fatalf("continue returned error: %s", text)
+	}
+	if !strings.Contains(text, "main.go:13") {
+		t.Errorf("Expected to stop at main.go:13, got: %s", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
+func TestConditionalBreakpoint(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "loop")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	// Break inside the loop only once x reaches 5.
+	f := filepath.Join(ts.cwd, "testdata", "go", "loop", "main.go")
+	text, isErr := ts.callTool(t, "breakpoint", map[string]any{
+		"file":      f,
+		"line":      9,
+		"condition": "x == 5",
+	})
+	if isErr {
+		t.Fatalf("Failed to set conditional breakpoint: %s", text)
+	}
+
+	text, isErr = ts.callTool(t, "continue", map[string]any{})
+	if isErr {
+		t.Fatalf("continue returned error: %s", text)
+	}
+
+	evalText, isErr := ts.callTool(t, "evaluate", map[string]any{"expression": "x"})
+	if isErr {
+		t.Fatalf("evaluate returned error: %s", evalText)
+	}
+	if !strings.HasPrefix(evalText, "5") {
+		t.Errorf("Expected x to be 5 at conditional breakpoint, got: %s", evalText)
+	}
+
+	ts.stopDebugger(t)
+}
+
+func TestFailedBreakpointKeepsPrevious(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "loop")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	f := filepath.Join(ts.cwd, "testdata", "go", "loop", "main.go")
+	text, isErr := ts.callTool(t, "breakpoint", map[string]any{"file": f, "line": 9})
+	if isErr {
+		t.Fatalf("Failed to set breakpoint: %s", text)
+	}
+
+	// Replacing it with a breakpoint the adapter rejects must leave the
+	// first one in place.
+	text, isErr = ts.callTool(t, "breakpoint", map[string]any{
+		"file":      f,
+		"line":      9,
+		"condition": "x ==",
+	})
+	if !isErr {
+		t.Fatalf("Expected an error for an invalid condition, got: %s", text)
+	}
+	// Setting another breakpoint in the file resends the file's whole set.
+	text, isErr = ts.callTool(t, "breakpoint", map[string]any{
+		"file":      f,
+		"line":      8,
+		"condition": "x == 3",
+	})
+	if isErr {
+		t.Fatalf("Failed to set conditional breakpoint: %s", text)
+	}
+
+	text, isErr = ts.callTool(t, "continue", map[string]any{})
+	if isErr {
+		t.Fatalf("continue returned error: %s", text)
+	}
+	if !strings.Contains(text, "main.go:9") {
+		t.Errorf("Expected to stop at main.go:9, got: %s", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
+func TestOutput(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "helloworld")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	// Run to completion; the program prints its greeting on the way.
+	text, isErr := ts.callTool(t, "continue", map[string]any{})
+	if isErr {
+		t.Fatalf("continue returned error: %s", text)
+	}
+
+	text, isErr = ts.callTool(t, "output", map[string]any{"category": "stdout"})
+	if isErr {
+		t.Fatalf("output returned error: %s", text)
+	}
+	if !strings.Contains(text, "[stdout] hello, world") {
+		t.Errorf("Expected program output to contain 'hello, world', got: %s", text)
+	}
+
+	// A second call only returns output received since the first.
+	text, _ = ts.callTool(t, "output", map[string]any{})
+	if strings.Contains(text, "hello, world") {
+		t.Errorf("Expected already-read output not to be returned again, got: %s", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
 func TestInfo(t *testing.T) {
 	ts := setupMCPServerAndClient(t)
 	defer ts.cleanup()
@@ -1452,6 +1913,132 @@ func TestInfo(t *testing.T) {
 	ts.stopDebugger(t)
 }
 
+func TestInfoThreads(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "goroutines")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	// Stop once the workers and lockers are blocked
+	f := filepath.Join(ts.cwd, "testdata", "go", "goroutines", "main.go")
+	ts.setBreakpointAndContinue(t, f, 34)
+
+	// Each goroutine is listed with its current frame
+	var info InfoResult
+	ts.callToolStructured(t, "info", map[string]any{"type": "threads"}, &info)
+	if info.Total < 14 || len(info.Threads) != info.Total {
+		t.Fatalf("Expected at least 14 goroutines, all listed, got total %d and %d listed", info.Total, len(info.Threads))
+	}
+	for _, th := range info.Threads {
+		if len(th.Frames) != 1 {
+			t.Errorf("Expected one frame for thread %d, got: %+v", th.ID, th.Frames)
+		}
+	}
+
+	// Filtering matches functions anywhere in the fetched frames
+	info = InfoResult{}
+	ts.callToolStructured(t, "info", map[string]any{"type": "threads", "filter": `sync\.runtime_SemacquireMutex`, "depth": 5}, &info)
+	if info.Total != 3 {
+		t.Errorf("Expected the 3 goroutines waiting on the mutex, got: %+v", info.Threads)
+	}
+
+	// Paging
+	info = InfoResult{}
+	ts.callToolStructured(t, "info", map[string]any{"type": "threads", "filter": "main\\.worker", "count": 4, "start": 4}, &info)
+	if info.Total != 10 || len(info.Threads) != 4 || info.NextStart != 8 {
+		t.Errorf("Expected workers 5-8 of 10 with next start 8, got total %d, %d threads, next start %d", info.Total, len(info.Threads), info.NextStart)
+	}
+
+	// Grouping puts the workers blocked on the same channel receive together
+	info = InfoResult{}
+	ts.callToolStructured(t, "info", map[string]any{"type": "threads", "group": true}, &info)
+	if len(info.Groups) == 0 || info.Groups[0].Count != 10 || len(info.Groups[0].ThreadIDs) != 10 {
+		t.Fatalf("Expected the largest group to be the 10 workers, got: %+v", info.Groups)
+	}
+	if !slices.ContainsFunc(info.Groups[0].Frames, func(f StackFrame) bool { return f.Name == "main.worker" }) {
+		t.Errorf("Expected the workers' group stack to contain main.worker, got: %+v", info.Groups[0].Frames)
+	}
+	text, isErr := ts.callTool(t, "info", map[string]any{"type": "threads", "group": true})
+	if isErr || !strings.Contains(text, "10 threads: ") {
+		t.Errorf("Expected a group of 10 threads in the text, got: %s", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
+func TestAnalyzeBlocking(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "goroutines")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	f := filepath.Join(ts.cwd, "testdata", "go", "goroutines", "main.go")
+	ts.setBreakpointAndContinue(t, f, 34)
+
+	var out BlockingResult
+	ts.callToolStructured(t, "analyze-blocking", map[string]any{}, &out)
+	states := make(map[string]int)
+	for _, bt := range out.Threads {
+		states[bt.State]++
+		if bt.State == "mutex" && (bt.Location == nil || bt.Location.Name != "main.locker") {
+			t.Errorf("Expected goroutine %d to be blocked in main.locker, got: %+v", bt.ID, bt.Location)
+		}
+	}
+	if states["chan receive"] != 10 || states["mutex"] != 3 || states["running"] != 1 {
+		t.Errorf("Expected 10 goroutines receiving, 3 locking and 1 running, got: %v", states)
+	}
+	if out.Deadlock {
+		t.Error("Expected no deadlock while main is running")
+	}
+
+	// The workers wait on the same channel, with nobody sending
+	var recv *WaitRelation
+	for i, r := range out.Relations {
+		if r.State == "chan receive" {
+			recv = &out.Relations[i]
+		}
+	}
+	if recv == nil || len(recv.Waiters) != 10 || !strings.HasPrefix(recv.Object, "0x") || !strings.Contains(recv.Note, "no goroutine is blocked sending") {
+		t.Errorf("Expected the 10 workers to wait on one channel with no sender, got: %+v", out.Relations)
+	}
+
+	ts.stopDebugger(t)
+}
+
+func TestAnalyzeBlockingPausesRunningProgram(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "loop")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	text, isErr := ts.callTool(t, "continue", map[string]any{"timeout": 1})
+	if isErr || !strings.Contains(text, "still running") {
+		t.Fatalf("Expected 'still running', got: %s", text)
+	}
+
+	text, isErr = ts.callTool(t, "analyze-blocking", map[string]any{})
+	if isErr || !strings.Contains(text, "Analyzed") {
+		t.Fatalf("analyze-blocking failed: %s", text)
+	}
+
+	// The program stays paused
+	text, isErr = ts.callTool(t, "context", map[string]any{})
+	if isErr {
+		t.Errorf("Expected context to work after analyze-blocking paused the program, got: %s", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
 func TestDisassemble(t *testing.T) {
 	ts := setupMCPServerAndClient(t)
 	defer ts.cleanup()
@@ -1504,6 +2091,50 @@ func TestDisassemble(t *testing.T) {
 	ts.stopDebugger(t)
 }
 
+func TestStepInstruction(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "step")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	f := filepath.Join(ts.cwd, "testdata", "go", "step", "main.go")
+	ts.setBreakpointAndContinue(t, f, 7)
+
+	var stop StopResult
+	ts.callToolStructured(t, "step", map[string]any{"mode": "over", "granularity": "instruction"}, &stop)
+	if stop.Location == nil || stop.Location.InstructionPointer == "" || len(stop.Instructions) == 0 {
+		t.Fatalf("step by instruction = %+v, want a location with the next instructions", stop)
+	}
+	if stop.Instructions[0].Address != stop.Location.InstructionPointer {
+		t.Errorf("first instruction at %s, want the instruction pointer %s", stop.Instructions[0].Address, stop.Location.InstructionPointer)
+	}
+
+	// Stop at an instruction further on, taken from the disassembly.
+	var dis DisassembleResult
+	ts.callToolStructured(t, "disassemble", map[string]any{"address": stop.Location.InstructionPointer, "count": 4}, &dis)
+	if len(dis.Instructions) < 4 {
+		t.Fatalf("got %d instructions, want 4", len(dis.Instructions))
+	}
+	target := dis.Instructions[3].Address
+	if text, isErr := ts.callTool(t, "instruction-breakpoint", map[string]any{"address": target}); isErr {
+		t.Fatalf("instruction-breakpoint returned error: %s", text)
+	}
+	ts.callToolStructured(t, "continue", map[string]any{}, &stop)
+	if stop.Location == nil || stop.Location.InstructionPointer != target {
+		t.Errorf("continue stopped at %+v, want instruction %s", stop.Location, target)
+	}
+
+	text, _ := ts.callTool(t, "instruction-breakpoint", map[string]any{"address": target, "remove": true})
+	if !strings.Contains(text, "No instruction breakpoints set") {
+		t.Errorf("after removing the breakpoint, got: %s", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
 func TestSetVariable(t *testing.T) {
 	ts := setupMCPServerAndClient(t)
 	defer ts.cleanup()
@@ -1568,8 +2199,7 @@ func TestPause(t *testing.T) {
 	ts.setBreakpointAndContinue(t, f, 7)
 
 	// Call pause while already stopped — exercises the pause code path.
-	// Full concurrent pause (continue + pause) requires concurrent DAP reads
-	// which is not supported by the current single-reader architecture.
+	// Pausing a running program is covered by TestContinueTimeout.
 	text, isErr := ts.callTool(t, "pause", map[string]any{"threadId": 1})
 	if isErr {
 		t.Fatalf("pause returned error: %s", text)
@@ -1582,6 +2212,255 @@ func TestPause(t *testing.T) {
 	ts.stopDebugger(t)
 }
 
+func TestContinueTimeout(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "loop")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	// The loop never exits, so continue returns once the timeout expires.
+	text, isErr := ts.callTool(t, "continue", map[string]any{"timeout": 1})
+	if isErr {
+		t.Fatalf("continue returned error: %s", text)
+	}
+	if !strings.Contains(text, "still running") {
+		t.Fatalf("Expected 'still running', got: %s", text)
+	}
+
+	// Tools that need a stopped program are rejected while it runs.
+	text, isErr = ts.callTool(t, "context", map[string]any{})
+	if !isErr || !strings.Contains(text, "program is running") {
+		t.Errorf("Expected running error from context, got (isErr=%v): %s", isErr, text)
+	}
+
+	text, isErr = ts.callTool(t, "wait", map[string]any{"timeout": 1})
+	if isErr {
+		t.Fatalf("wait returned error: %s", text)
+	}
+	if !strings.Contains(text, "still running") {
+		t.Errorf("Expected 'still running' from wait, got: %s", text)
+	}
+
+	// Pausing reports the stop caused by the pause.
+	text, isErr = ts.callTool(t, "pause", map[string]any{})
+	if isErr {
+		t.Fatalf("pause returned error: %s", text)
+	}
+	if !strings.Contains(text, "Stopped") {
+		t.Errorf("Expected stop summary from pause, got: %s", text)
+	}
+
+	// The session is usable again.
+	text, isErr = ts.callTool(t, "context", map[string]any{})
+	if isErr {
+		t.Fatalf("context returned error after pause: %s", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
+// startHeadlessDelve starts 'dlv exec --headless --accept-multiclient' on
+// binaryPath and returns the address it listens on.
+func startHeadlessDelve(t *testing.T, binaryPath string) string {
+	t.Helper()
+	if _, err := exec.LookPath("dlv"); err != nil {
+		t.Skip("dlv not found in PATH")
+	}
+	cmd := exec.Command("dlv", "exec", binaryPath, "--headless", "--accept-multiclient", "--api-version=2", "--listen", "127.0.0.1:0")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start headless dlv: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	r := bufio.NewReader(stdout)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("headless dlv exited before listening: %v", err)
+		}
+		if addr, ok := strings.CutPrefix(line, "API server listening at: "); ok {
+			go io.Copy(io.Discard, r)
+			return strings.TrimSpace(addr)
+		}
+	}
+}
+
+func TestRemoteMode(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "helloworld")
+	defer cleanupBinary()
+	addr := startHeadlessDelve(t, binaryPath)
+
+	f := filepath.Join(ts.cwd, "testdata", "go", "helloworld", "main.go")
+	text, isErr := ts.callTool(t, "debug", map[string]any{
+		"mode":    "remote",
+		"address": addr,
+		"breakpoints": []map[string]any{
+			{"file": f, "line": 7},
+		},
+	})
+	if isErr {
+		t.Fatalf("remote debug session returned error: %s", text)
+	}
+
+	contextStr := ts.getContextContent(t)
+	if !strings.Contains(contextStr, "main.main") {
+		t.Errorf("Expected context to contain 'main.main', got: %s", contextStr)
+	}
+
+	text, isErr = ts.callTool(t, "stop", map[string]any{})
+	if isErr {
+		t.Fatalf("stop returned error: %s", text)
+	}
+	if !strings.Contains(text, "Disconnected") {
+		t.Errorf("Expected 'Disconnected' from stop in remote mode, got: %s", text)
+	}
+
+	// The server keeps running and accepts a new connection.
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("headless server not reachable after stop: %v", err)
+	}
+	conn.Close()
+}
+
+// startDAPDelve starts 'dlv dap --listen' and returns its address.
+func startDAPDelve(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("dlv"); err != nil {
+		t.Skip("dlv not found in PATH")
+	}
+	cmd := exec.Command("dlv", "dap", "--listen", "127.0.0.1:0")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dlv dap: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	r := bufio.NewReader(stdout)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("dlv dap exited before listening: %v", err)
+		}
+		if addr, ok := strings.CutPrefix(line, "DAP server listening at: "); ok {
+			go io.Copy(io.Discard, r)
+			return strings.TrimSpace(addr)
+		}
+	}
+}
+
+func TestRemoteModeDAPServer(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	// A 'dlv dap' server has no target until a client launches one, so
+	// remote mode cannot attach to it.
+	addr := startDAPDelve(t)
+	text, isErr := ts.callTool(t, "debug", map[string]any{
+		"mode":    "remote",
+		"address": addr,
+	})
+	if !isErr {
+		t.Fatalf("Expected remote mode against 'dlv dap' to fail, got: %s", text)
+	}
+	if !strings.Contains(text, "--headless --accept-multiclient") {
+		t.Errorf("Expected the error to name the server remote mode needs, got: %s", text)
+	}
+}
+
+func TestMultipleSessions(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "helloworld")
+	defer cleanupBinary()
+
+	f := filepath.Join(ts.cwd, "testdata", "go", "helloworld", "main.go")
+	for _, name := range []string{"a", "b"} {
+		text, isErr := ts.callTool(t, "debug", map[string]any{
+			"session": name,
+			"mode":    "binary",
+			"path":    binaryPath,
+			"breakpoints": []map[string]any{
+				{"file": f, "line": 7},
+			},
+		})
+		if isErr {
+			t.Fatalf("debug session %s returned error: %s", name, text)
+		}
+	}
+
+	text, _ := ts.callTool(t, "sessions", map[string]any{})
+	if !strings.Contains(text, "  a: binary") || !strings.Contains(text, "* b: binary") {
+		t.Errorf("Expected sessions a and b with b current, got: %s", text)
+	}
+
+	// Both sessions can be driven independently.
+	for _, name := range []string{"a", "b"} {
+		text, isErr := ts.callTool(t, "context", map[string]any{"session": name})
+		if isErr || !strings.Contains(text, "main.main") {
+			t.Errorf("context for session %s: %s", name, text)
+		}
+	}
+
+	text, isErr := ts.callTool(t, "stop", map[string]any{"session": "b"})
+	if isErr {
+		t.Fatalf("stop b returned error: %s", text)
+	}
+
+	// Tools without a session parameter now apply to the remaining session.
+	text, isErr = ts.callTool(t, "context", map[string]any{})
+	if isErr || !strings.Contains(text, "main.main") {
+		t.Errorf("context after stopping b: %s", text)
+	}
+	text, isErr = ts.callTool(t, "context", map[string]any{"session": "b"})
+	if !isErr {
+		t.Errorf("Expected error for stopped session b, got: %s", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
+func TestExceptionInfoOnPanic(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "buggy")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, []map[string]any{{"function": "main.main"}})
+
+	// The last test case indexes past the end of the slice and panics.
+	text, isErr := ts.callTool(t, "continue", map[string]any{})
+	if isErr {
+		t.Fatalf("continue returned error: %s", text)
+	}
+	if !strings.Contains(text, "## Exception") || !strings.Contains(text, "index out of range") {
+		t.Errorf("Expected exception details in stop summary, got: %s", text)
+	}
+
+	ts.stopDebugger(t)
+}
+
 func TestStepIn(t *testing.T) {
 	ts := setupMCPServerAndClient(t)
 	defer ts.cleanup()
@@ -1654,6 +2533,30 @@ func TestStepOut(t *testing.T) {
 	ts.stopDebugger(t)
 }
 
+func TestStepBackUnsupported(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "step")
+	defer cleanupBinary()
+
+	ts.startDebugSession(t, "0", binaryPath, nil)
+
+	f := filepath.Join(ts.cwd, "testdata", "go", "step", "main.go")
+	ts.setBreakpointAndContinue(t, f, 7)
+
+	// Delve's native backend cannot run backwards.
+	text, isErr := ts.callTool(t, "step", map[string]any{"mode": "back"})
+	if !isErr || !strings.Contains(text, "not supported") {
+		t.Errorf("step back: got %q (error %v), want a 'not supported' error", text, isErr)
+	}
+	if _, isErr := ts.callTool(t, "continue", map[string]any{"reverse": true}); !isErr {
+		t.Error("reverse continue succeeded without reverse execution support")
+	}
+
+	ts.stopDebugger(t)
+}
+
 func TestErrorBeforeDebuggerStarted(t *testing.T) {
 	ts := setupMCPServerAndClient(t)
 	defer ts.cleanup()
@@ -1690,3 +2593,124 @@ func TestErrorBeforeDebuggerStarted(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordReplayValidation(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"replay without trace", map[string]any{"mode": "replay"}, "traceDir is required"},
+		{"record without path", map[string]any{"mode": "record"}, "path is required"},
+		{"record with gdb", map[string]any{"mode": "record", "path": "/bin/true", "debugger": "gdb", "gdbPath": "/bin/true"}, "only supported with Delve"},
+		{"trace outside record and replay", map[string]any{"mode": "binary", "path": "/bin/true", "traceDir": "/tmp/trace"}, "traceDir is only used"},
+		{"record with gcflags", map[string]any{"mode": "record", "path": "main.go", "buildFlags": "-gcflags=all=-m"}, "remove -gcflags"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isErr := ts.callTool(t, "debug", tt.args)
+			if !isErr || !strings.Contains(text, tt.want) {
+				t.Errorf("debug %v: got %q (error %v), want an error containing %q", tt.args, text, isErr, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.AllowedModes = []string{"binary"}
+	cfg.FullContext = true
+	cfg.MaxFrames = 1
+	ts := setupMCPServerAndClientWithConfig(t, cfg)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "helloworld")
+	defer cleanupBinary()
+	f := filepath.Join(ts.cwd, "testdata", "go", "helloworld", "main.go")
+
+	text, isErr := ts.callTool(t, "debug", map[string]any{"mode": "source", "path": f})
+	if !isErr || !strings.Contains(text, "source mode is disabled") {
+		t.Errorf("debug in a disallowed mode: got %q (error %v), want it rejected", text, isErr)
+	}
+
+	// Stops return the full context by default, unless a call asks for a
+	// compact summary.
+	var stop StopResult
+	ts.callToolStructured(t, "debug", map[string]any{
+		"mode":        "binary",
+		"path":        binaryPath,
+		"breakpoints": []map[string]any{{"file": f, "line": 7}},
+	}, &stop)
+	if stop.Context == nil || len(stop.Context.StackTrace) != 1 {
+		t.Fatalf("debug stop: want the full context with 1 frame, got %+v", stop.Context)
+	}
+	var step StopResult
+	ts.callToolStructured(t, "step", map[string]any{"mode": "over", "fullContext": false}, &step)
+	if step.Context != nil {
+		t.Errorf("step with fullContext false: got the full context %+v", step.Context)
+	}
+
+	var c ContextResult
+	ts.callToolStructured(t, "context", map[string]any{}, &c)
+	if len(c.StackTrace) != 1 {
+		t.Errorf("context: got %d frames, want the configured 1", len(c.StackTrace))
+	}
+
+	ts.stopDebugger(t)
+}
+
+func TestLaunchEnvironment(t *testing.T) {
+	ts := setupMCPServerAndClient(t)
+	defer ts.cleanup()
+
+	binaryPath, cleanupBinary := compileTestProgram(t, ts.cwd, "env")
+	defer cleanupBinary()
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "app.env")
+	if err := os.WriteFile(envFile, []byte("# test settings\nCONFIG_PATH=/etc/app.yaml\nMODE=file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	text, isErr := ts.callTool(t, "debug", map[string]any{
+		"mode":    "binary",
+		"path":    binaryPath,
+		"envFile": envFile,
+		"env":     map[string]any{"MODE": "call"},
+		"cwd":     dir,
+	})
+	if isErr {
+		t.Fatalf("debug returned error: %s", text)
+	}
+	if text, isErr = ts.callTool(t, "continue", map[string]any{}); isErr {
+		t.Fatalf("continue returned error: %s", text)
+	}
+	text, _ = ts.callTool(t, "output", map[string]any{"category": "stdout"})
+	for _, want := range []string{"CONFIG_PATH=/etc/app.yaml", "MODE=call", "cwd=" + dir} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected program output to contain %q, got: %s", want, text)
+		}
+	}
+	ts.stopDebugger(t)
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"env when attaching", map[string]any{"mode": "attach", "processId": 1, "env": map[string]any{"A": "1"}}, "only used by the source, binary and record modes"},
+		{"build flags for a binary", map[string]any{"mode": "binary", "path": "/bin/true", "buildFlags": "-race"}, "only used when Delve builds the program"},
+		{"missing envFile", map[string]any{"mode": "binary", "path": "/bin/true", "envFile": filepath.Join(dir, "missing.env")}, "unable to read envFile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isErr := ts.callTool(t, "debug", tt.args)
+			if !isErr || !strings.Contains(text, tt.want) {
+				t.Errorf("debug %v: got %q (error %v), want an error containing %q", tt.args, text, isErr, tt.want)
+			}
+		})
+	}
+}
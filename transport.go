@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// httpSessionTimeout closes streamable HTTP sessions, and ends their debug
+// sessions, after this long without a request from the client. Clients that
+// disconnect without ending their session would otherwise leave debuggers
+// running forever.
+const httpSessionTimeout = time.Hour
+
+// newServer creates an MCP server with the debugger tools, prompts and
+// resources, and its own debug sessions.
+func newServer(logWriter io.Writer, cfg *config, opts *mcp.ServerOptions) (*mcp.Server, *sessionManager) {
+	implementation := mcp.Implementation{
+		Name:    "mcp-dap-server",
+		Version: version,
+	}
+	subscriptions := newResourceSubscriptions()
+	serverOpts := subscriptions.serverOptions()
+	if opts != nil {
+		serverOpts.InitializedHandler = opts.InitializedHandler
+	}
+	server := mcp.NewServer(&implementation, serverOpts)
+
+	sessions := registerTools(server, logWriter, cfg)
+	registerPrompts(server)
+	registerResources(server, sessions, subscriptions)
+	return server, sessions
+}
+
+// connections tracks the session managers of the clients connected over
+// HTTP. Each connection has its own MCP server, so clients never see or
+// control each other's debug sessions.
+type connections struct {
+	logWriter io.Writer
+	config    *config
+
+	mu       sync.Mutex
+	managers map[*sessionManager]struct{}
+}
+
+func newConnections(logWriter io.Writer, cfg *config) *connections {
+	return &connections{logWriter: logWriter, config: cfg, managers: make(map[*sessionManager]struct{})}
+}
+
+// server creates the MCP server for a new connection. Its debug sessions end
+// when the client disconnects.
+func (c *connections) server(*http.Request) *mcp.Server {
+	var sessions *sessionManager
+	server, sessions := newServer(c.logWriter, c.config, &mcp.ServerOptions{
+		InitializedHandler: func(_ context.Context, req *mcp.InitializedRequest) {
+			go func() {
+				req.Session.Wait()
+				c.remove(sessions)
+			}()
+		},
+	})
+	c.mu.Lock()
+	c.managers[sessions] = struct{}{}
+	c.mu.Unlock()
+	return server
+}
+
+// remove ends a connection's debug sessions.
+func (c *connections) remove(m *sessionManager) {
+	c.mu.Lock()
+	delete(c.managers, m)
+	c.mu.Unlock()
+	m.cleanup()
+}
+
+// cleanup ends the debug sessions of every connection.
+func (c *connections) cleanup() {
+	c.mu.Lock()
+	managers := c.managers
+	c.managers = make(map[*sessionManager]struct{})
+	c.mu.Unlock()
+	for m := range managers {
+		m.cleanup()
+	}
+}
+
+// httpHandler serves MCP over transport, "sse" or "http" (streamable HTTP).
+// If token is set, requests must carry it as a bearer token.
+func (c *connections) httpHandler(transport, token string) (http.Handler, error) {
+	var handler http.Handler
+	switch transport {
+	case "sse":
+		handler = mcp.NewSSEHandler(c.server, nil)
+	case "http":
+		handler = mcp.NewStreamableHTTPHandler(c.server, &mcp.StreamableHTTPOptions{SessionTimeout: httpSessionTimeout})
+	default:
+		return nil, fmt.Errorf("unsupported HTTP transport: %s (must be 'sse' or 'http')", transport)
+	}
+	if token != "" {
+		handler = auth.RequireBearerToken(tokenVerifier(token), nil)(handler)
+	}
+	return handler, nil
+}
+
+// tokenVerifier accepts only the given bearer token.
+func tokenVerifier(token string) auth.TokenVerifier {
+	return func(_ context.Context, got string, _ *http.Request) (*auth.TokenInfo, error) {
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return nil, fmt.Errorf("%w: wrong bearer token", auth.ErrInvalidToken)
+		}
+		// The token does not expire, but the SDK requires an expiration.
+		return &auth.TokenInfo{Expiration: time.Now().Add(24 * time.Hour)}, nil
+	}
+}
+
+// serveHTTP serves MCP over cfg's HTTP transport on its listen address
+// until ctx is done, then ends every connection's debug sessions.
+func serveHTTP(ctx context.Context, cfg *config, logWriter io.Writer) error {
+	conns := newConnections(logWriter, cfg)
+	defer conns.cleanup()
+	handler, err := conns.httpHandler(cfg.Transport, cfg.Token)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return err
+	}
+	if cfg.Token == "" && !isLoopback(ln.Addr()) {
+		log.Printf("warning: serving on %s without a bearer token; anyone who can reach it can run programs under the debugger", ln.Addr())
+	}
+	log.Printf("serving MCP over %s on %s", cfg.Transport, ln.Addr())
+
+	srv := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// isLoopback reports whether addr only accepts local connections.
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
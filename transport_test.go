@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// bearerTransport adds a bearer token to every request.
+type bearerTransport struct {
+	token string
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// connectHTTP connects a client to the streamable HTTP endpoint at url,
+// sending token if it is set.
+func connectHTTP(ctx context.Context, url, token string) (*mcp.ClientSession, error) {
+	transport := &mcp.StreamableClientTransport{Endpoint: url}
+	if token != "" {
+		transport.HTTPClient = &http.Client{Transport: bearerTransport{token}}
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v1.0.0"}, nil)
+	return client.Connect(ctx, transport, nil)
+}
+
+func TestHTTPTransportToken(t *testing.T) {
+	conns := newConnections(io.Discard, defaultConfig())
+	defer conns.cleanup()
+	handler, err := conns.httpHandler("http", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	ctx := context.Background()
+
+	for _, token := range []string{"", "wrong"} {
+		if session, err := connectHTTP(ctx, srv.URL, token); err == nil {
+			session.Close()
+			t.Errorf("connecting with token %q succeeded, want an authorization error", token)
+		}
+	}
+
+	session, err := connectHTTP(ctx, srv.URL, "secret")
+	if err != nil {
+		t.Fatalf("connecting with the token: %v", err)
+	}
+	defer session.Close()
+	if _, err := session.ListTools(ctx, nil); err != nil {
+		t.Errorf("ListTools: %v", err)
+	}
+
+	if _, err := conns.httpHandler("websocket", ""); err == nil {
+		t.Error("httpHandler accepted an unsupported transport")
+	}
+}
+
+// TestHTTPTransportSessionsPerConnection checks that each client has its own
+// debug sessions, which end when it disconnects.
+func TestHTTPTransportSessionsPerConnection(t *testing.T) {
+	conns := newConnections(io.Discard, defaultConfig())
+	defer conns.cleanup()
+	handler, err := conns.httpHandler("http", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	ctx := context.Background()
+
+	a, err := connectHTTP(ctx, srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := connectHTTP(ctx, srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath, cleanupBinary := compileTestProgram(t, cwd, "helloworld")
+	defer cleanupBinary()
+	res, err := a.CallTool(ctx, &mcp.CallToolParams{Name: "debug", Arguments: map[string]any{"mode": "binary", "path": binaryPath}})
+	if err != nil || res.IsError {
+		t.Fatalf("debug on the first connection: %v, %+v", err, res)
+	}
+
+	tools, err := b.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.ContainsFunc(tools.Tools, func(tool *mcp.Tool) bool { return tool.Name == "stop" }) {
+		t.Error("the second connection sees the first connection's debug session tools")
+	}
+	res, err = b.CallTool(ctx, &mcp.CallToolParams{Name: "sessions"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "No debug sessions") {
+		t.Errorf("sessions on the second connection = %q, want none", text)
+	}
+
+	// Disconnecting ends the first connection's debug session.
+	conns.mu.Lock()
+	managers := len(conns.managers)
+	conns.mu.Unlock()
+	if managers != 2 {
+		t.Fatalf("got %d connections, want 2", managers)
+	}
+	a.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conns.mu.Lock()
+		managers = len(conns.managers)
+		conns.mu.Unlock()
+		if managers == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the first connection's debug sessions did not end after it disconnected")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
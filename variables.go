@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultInspectDepth = 1   // levels of children expanded by 'inspect'
+	maxInspectDepth     = 5   // deepest expansion 'inspect' allows
+	defaultInspectCount = 100 // children fetched per variable by 'inspect'
+)
+
+// formatVariable renders a variable as "name (type) = value", followed by
+// its variablesReference and child count when it can be expanded.
+func formatVariable(v Variable) string {
+	var s string
+	if v.Type != "" {
+		s = fmt.Sprintf("%s (%s) = %s", v.Name, v.Type, v.Value)
+	} else {
+		s = fmt.Sprintf("%s = %s", v.Name, v.Value)
+	}
+	if v.VariablesReference > 0 {
+		s += fmt.Sprintf(" [ref %d%s]", v.VariablesReference, childCountSuffix(v.NamedVariables, v.IndexedVariables))
+	}
+	return s
+}
+
+// childCountSuffix describes how many children a variable has, when the
+// adapter reported it.
+func childCountSuffix(named, indexed int) string {
+	switch {
+	case indexed > 0 && named > 0:
+		return fmt.Sprintf(", %d fields, %d elements", named, indexed)
+	case indexed > 0:
+		return fmt.Sprintf(", %d elements", indexed)
+	case named > 0:
+		return fmt.Sprintf(", %d fields", named)
+	}
+	return ""
+}
+
+// InspectParams defines the parameters for expanding a variable's children.
+type InspectParams struct {
+	SessionParam
+	VariablesReference FlexInt  `json:"variablesReference,omitempty" mcp:"variablesReference of the variable to expand, as shown by 'context' ([ref N])"`
+	Path               string   `json:"path,omitempty" mcp:"expression naming the variable to expand, e.g. 'req.Header[\"X\"]' (alternative to variablesReference)"`
+	FrameID            *FlexInt `json:"frameId,omitempty" mcp:"stack frame in which to evaluate path (default: current frame)"`
+	Depth              FlexInt  `json:"depth,omitempty" mcp:"levels of children to expand (default: 1, max: 5)"`
+	Start              FlexInt  `json:"start,omitempty" mcp:"index of the first element to show, for paging through large collections (default: 0)"`
+	Count              FlexInt  `json:"count,omitempty" mcp:"maximum children shown per variable (default: 100)"`
+}
+
+// inspect expands a variable's children to a configurable depth.
+func (ds *debuggerSession) inspect(ctx context.Context, _ *mcp.CallToolRequest, params InspectParams) (*mcp.CallToolResult, *InspectResult, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.client == nil {
+		return nil, nil, fmt.Errorf("debugger not started")
+	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
+
+	depth := params.Depth.Int()
+	if depth <= 0 {
+		depth = defaultInspectDepth
+	}
+	depth = min(depth, maxInspectDepth)
+	count := params.Count.Int()
+	if count <= 0 {
+		count = defaultInspectCount
+	}
+
+	var result strings.Builder
+	root := Variable{VariablesReference: params.VariablesReference.Int()}
+	out := &InspectResult{Children: []InspectedVariable{}}
+	switch {
+	case params.Path != "":
+		frameID := ds.lastFrameID
+		if params.FrameID != nil {
+			frameID = params.FrameID.Int()
+		}
+		if frameID < 0 {
+			frameID = 0
+		}
+		seq, err := ds.client.EvaluateRequest(params.Path, frameID, "watch")
+		if err != nil {
+			return nil, nil, err
+		}
+		resp, err := readTypedResponse[*dap.EvaluateResponse](ds.client, seq)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to evaluate %s: %w", params.Path, err)
+		}
+		root = Variable{
+			Name:               params.Path,
+			Value:              resp.Body.Result,
+			Type:               resp.Body.Type,
+			VariablesReference: resp.Body.VariablesReference,
+			NamedVariables:     resp.Body.NamedVariables,
+			IndexedVariables:   resp.Body.IndexedVariables,
+		}
+		ds.recordIndexed(root)
+		out.Variable = &root
+		out.Reference = root.VariablesReference
+		result.WriteString(formatVariable(root) + "\n")
+		if root.VariablesReference <= 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: result.String()}},
+			}, out, nil
+		}
+	case root.VariablesReference > 0:
+		// Paging needs the element count, which the reference alone does
+		// not carry; use the one shown with it.
+		root.IndexedVariables = ds.indexedCounts[root.VariablesReference]
+		out.Reference = root.VariablesReference
+		fmt.Fprintf(&result, "[ref %d]\n", root.VariablesReference)
+	default:
+		return nil, nil, fmt.Errorf("either variablesReference or path is required")
+	}
+
+	if err := ds.walkVariableTree(&result, out, root, params.Start.Int(), count, depth, 1); err != nil {
+		return nil, nil, err
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: result.String()}},
+	}, out, nil
+}
+
+// fetchChildren returns up to count children of v starting at start.
+// Indexed collections are paged with the "indexed" filter, which is how
+// adapters such as Delve expose elements beyond their default load limit.
+func (ds *debuggerSession) fetchChildren(v Variable, start, count int) ([]Variable, error) {
+	filter := ""
+	if v.IndexedVariables > 0 {
+		filter = "indexed"
+		count = min(count, v.IndexedVariables-start)
+		if count <= 0 {
+			return nil, nil
+		}
+	}
+	seq, err := ds.client.PagedVariablesRequest(v.VariablesReference, filter, start, count)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := readTypedResponse[*dap.VariablesResponse](ds.client, seq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get variables for ref %d: %w", v.VariablesReference, err)
+	}
+	children := resp.Body.Variables
+	if len(children) > count {
+		// Not every adapter honors count for unfiltered requests.
+		children = children[:count]
+	}
+	vs := newVariables(children)
+	ds.recordIndexed(vs...)
+	return vs, nil
+}
+
+// recordIndexed remembers the element counts of the indexed collections
+// among vs, so that 'inspect' can page through them by reference alone.
+func (ds *debuggerSession) recordIndexed(vs ...Variable) {
+	for _, v := range vs {
+		if v.VariablesReference <= 0 || v.IndexedVariables <= 0 {
+			continue
+		}
+		if ds.indexedCounts == nil {
+			ds.indexedCounts = make(map[int]int)
+		}
+		ds.indexedCounts[v.VariablesReference] = v.IndexedVariables
+	}
+}
+
+// walkVariableTree adds v's children, and their children down to depth
+// levels, to out and writes them to result one per line with increasing
+// indentation. level is the depth of v's children. start applies only to
+// v's own children. Collections with more children than were shown end
+// with a note on how to page further.
+func (ds *debuggerSession) walkVariableTree(result *strings.Builder, out *InspectResult, v Variable, start, count, depth, level int) error {
+	children, err := ds.fetchChildren(v, start, count)
+	if err != nil {
+		return err
+	}
+	indent := strings.Repeat("  ", level)
+	for _, child := range children {
+		fmt.Fprintf(result, "%s%s\n", indent, formatVariable(child))
+		out.Children = append(out.Children, InspectedVariable{Variable: child, Depth: level})
+		if depth > 1 && child.VariablesReference > 0 {
+			i := len(out.Children) - 1
+			if err := ds.walkVariableTree(result, out, child, 0, count, depth-1, level+1); err != nil {
+				out.Children[i].Error = err.Error()
+				fmt.Fprintf(result, "%s  (%v)\n", indent, err)
+			}
+		}
+	}
+	if v.IndexedVariables > 0 {
+		if next := start + len(children); next < v.IndexedVariables {
+			out.More = append(out.More, MoreElements{
+				VariablesReference: v.VariablesReference,
+				Remaining:          v.IndexedVariables - next,
+				NextStart:          next,
+			})
+			fmt.Fprintf(result, "%s... %d more elements (inspect variablesReference %d with start=%d)\n",
+				indent, v.IndexedVariables-next, v.VariablesReference, next)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestFormatVariable(t *testing.T) {
+	tests := []struct {
+		v    Variable
+		want string
+	}{
+		{Variable{Name: "x", Type: "int", Value: "10"}, "x (int) = 10"},
+		{Variable{Name: "x", Value: "10"}, "x = 10"},
+		{
+			Variable{Name: "nums", Type: "[]int", Value: "[]int len: 5, cap: 5, [...]", VariablesReference: 1005, IndexedVariables: 5},
+			"nums ([]int) = []int len: 5, cap: 5, [...] [ref 1005, 5 elements]",
+		},
+		{
+			Variable{Name: "p", Type: "main.Person", Value: "{...}", VariablesReference: 1006, NamedVariables: 2},
+			"p (main.Person) = {...} [ref 1006, 2 fields]",
+		},
+		{
+			Variable{Name: "ptr", Type: "*main.Person", Value: "0xc000010000", VariablesReference: 1007},
+			"ptr (*main.Person) = 0xc000010000 [ref 1007]",
+		},
+	}
+	for _, tt := range tests {
+		if got := formatVariable(tt.v); got != tt.want {
+			t.Errorf("formatVariable(%+v):\n got: %s\nwant: %s", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-dap"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// dataAccessTypes are the access types a data breakpoint can stop on.
+var dataAccessTypes = []dap.DataBreakpointAccessType{"write", "read", "readWrite"}
+
+// WatchParams defines the parameters for setting a data breakpoint.
+type WatchParams struct {
+	SessionParam
+	Name               string   `json:"name,omitempty" mcp:"variable to watch: a variable in the current frame, a child of variablesReference, or an expression; omit to list watches"`
+	VariablesReference FlexInt  `json:"variablesReference,omitempty" mcp:"variablesReference of the variable containing name, as shown by 'context' or 'inspect' ([ref N])"`
+	FrameID            *FlexInt `json:"frameId,omitempty" mcp:"stack frame in which to look up name (default: current frame)"`
+	AccessType         string   `json:"accessType,omitempty" mcp:"access that stops the program: 'write' (default), 'read' or 'readWrite'"`
+	Remove             bool     `json:"remove,omitempty" mcp:"stop watching name instead of adding a watch"`
+}
+
+// watch sets, removes or lists data breakpoints.
+func (ds *debuggerSession) watch(ctx context.Context, _ *mcp.CallToolRequest, params WatchParams) (*mcp.CallToolResult, *WatchResult, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.client == nil {
+		return nil, nil, fmt.Errorf("debugger not started")
+	}
+	if ds.running != nil {
+		return nil, nil, errRunning
+	}
+	if !ds.capabilities.SupportsDataBreakpoints {
+		return nil, nil, fmt.Errorf("debug adapter does not support data breakpoints")
+	}
+
+	if params.Name == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: formatWatches(ds.breakpoints.watches())}},
+		}, newWatchResult(ds.breakpoints.watches()), nil
+	}
+
+	if params.Remove {
+		if !ds.breakpoints.removeData(params.Name) {
+			return nil, nil, fmt.Errorf("no watch on %s", params.Name)
+		}
+		if _, err := ds.syncDataBreakpoints(); err != nil {
+			return nil, nil, err
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Stopped watching %s\n\n%s", params.Name, formatWatches(ds.breakpoints.watches()))}},
+		}, newWatchResult(ds.breakpoints.watches()), nil
+	}
+
+	accessType := dap.DataBreakpointAccessType(params.AccessType)
+	if accessType == "" {
+		accessType = "write"
+	}
+	if !slices.Contains(dataAccessTypes, accessType) {
+		return nil, nil, fmt.Errorf("invalid accessType: %s (must be 'write', 'read', or 'readWrite')", accessType)
+	}
+
+	frameID := ds.lastFrameID
+	if params.FrameID != nil {
+		frameID = params.FrameID.Int()
+	}
+	if frameID < 0 {
+		frameID = 0
+	}
+	info, err := ds.resolveDataBreakpoint(params.Name, params.VariablesReference.Int(), frameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	dataID, ok := dataIDString(info.DataId)
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot watch %s: %s", params.Name, info.Description)
+	}
+	if len(info.AccessTypes) > 0 && !slices.Contains(info.AccessTypes, accessType) {
+		return nil, nil, fmt.Errorf("cannot watch %s for %s access (supported: %s)", params.Name, accessType, joinAccessTypes(info.AccessTypes))
+	}
+
+	prev := ds.breakpoints.watches()
+	ds.breakpoints.setData(dataWatch{
+		DataBreakpoint: dap.DataBreakpoint{DataId: dataID, AccessType: accessType},
+		name:           params.Name,
+		description:    info.Description,
+		canPersist:     info.CanPersist,
+	})
+	bps, err := ds.syncDataBreakpoints()
+	if err != nil {
+		ds.breakpoints.data = prev
+		return nil, nil, err
+	}
+	for i, w := range ds.breakpoints.watches() {
+		if w.DataId != dataID || i >= len(bps) || bps[i].Verified {
+			continue
+		}
+		ds.breakpoints.data = prev
+		if _, err := ds.syncDataBreakpoints(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("data breakpoint not verified: %s", bps[i].Message)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Watching %s for %s access\n\n%s", params.Name, accessType, formatWatches(ds.breakpoints.watches()))}},
+	}, newWatchResult(ds.breakpoints.watches()), nil
+}
+
+// resolveDataBreakpoint asks the adapter for the dataId of name. Without a
+// variablesReference, name is looked up among the frame's scopes first and
+// then evaluated as an expression, since adapters differ in which of the two
+// they accept.
+func (ds *debuggerSession) resolveDataBreakpoint(name string, variablesRef, frameID int) (*dap.DataBreakpointInfoResponseBody, error) {
+	if variablesRef > 0 {
+		return ds.dataBreakpointInfo(variablesRef, name, 0)
+	}
+	seq, err := ds.client.ScopesRequest(frameID)
+	if err != nil {
+		return nil, err
+	}
+	if resp, err := readTypedResponse[*dap.ScopesResponse](ds.client, seq); err == nil {
+		for _, scope := range resp.Body.Scopes {
+			if scope.VariablesReference <= 0 {
+				continue
+			}
+			if info, err := ds.dataBreakpointInfo(scope.VariablesReference, name, 0); err == nil && info.DataId != nil {
+				return info, nil
+			}
+		}
+	}
+	return ds.dataBreakpointInfo(0, name, frameID)
+}
+
+// dataBreakpointInfo sends a dataBreakpointInfo request and returns its body.
+func (ds *debuggerSession) dataBreakpointInfo(variablesRef int, name string, frameID int) (*dap.DataBreakpointInfoResponseBody, error) {
+	seq, err := ds.client.DataBreakpointInfoRequest(variablesRef, name, frameID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := readTypedResponse[*dap.DataBreakpointInfoResponse](ds.client, seq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get data breakpoint info for %s: %w", name, err)
+	}
+	return &resp.Body, nil
+}
+
+// dataIDString converts a dataBreakpointInfo dataId to the string sent in
+// setDataBreakpoints. It reports false when the adapter returned null,
+// meaning the data cannot be watched.
+func dataIDString(id any) (string, bool) {
+	switch id := id.(type) {
+	case nil:
+		return "", false
+	case string:
+		return id, true
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64), true
+	default:
+		return fmt.Sprint(id), true
+	}
+}
+
+// joinAccessTypes lists access types for error messages.
+func joinAccessTypes(types []dap.DataBreakpointAccessType) string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = string(t)
+	}
+	return strings.Join(s, ", ")
+}
+
+// newWatchResult lists the registered data breakpoints.
+func newWatchResult(watches []dataWatch) *WatchResult {
+	out := &WatchResult{Watches: make([]Watch, len(watches))}
+	for i, w := range watches {
+		out.Watches[i] = Watch{Name: w.name, AccessType: string(w.AccessType), Description: w.description, DataID: w.DataId}
+	}
+	return out
+}
+
+// formatWatches describes the registered data breakpoints.
+func formatWatches(watches []dataWatch) string {
+	if len(watches) == 0 {
+		return "No watches set."
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Watches (%d):\n", len(watches))
+	for _, w := range watches {
+		fmt.Fprintf(&result, "  %s [%s]", w.name, w.AccessType)
+		if w.description != "" && w.description != w.name {
+			fmt.Fprintf(&result, " — %s", w.description)
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-dap"
+)
+
+func TestDataIDString(t *testing.T) {
+	tests := []struct {
+		id     any
+		want   string
+		wantOK bool
+	}{
+		{nil, "", false},
+		{"0xc000012345/8", "0xc000012345/8", true},
+		{float64(1000000), "1000000", true},
+	}
+	for _, tt := range tests {
+		got, ok := dataIDString(tt.id)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("dataIDString(%v) = %q, %v; want %q, %v", tt.id, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFormatWatches(t *testing.T) {
+	if got := formatWatches(nil); got != "No watches set." {
+		t.Errorf("formatWatches(nil) = %q", got)
+	}
+	got := formatWatches([]dataWatch{{
+		DataBreakpoint: dap.DataBreakpoint{DataId: "1", AccessType: "write"},
+		name:           "count",
+		description:    "count (4 bytes at 0x1000)",
+	}})
+	if !strings.Contains(got, "count [write] — count (4 bytes at 0x1000)") {
+		t.Errorf("unexpected formatWatches output: %q", got)
+	}
+}